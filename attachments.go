@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type linearAttachment struct {
+	URL        string                 `json:"url"`
+	Title      string                 `json:"title"`
+	Subtitle   string                 `json:"subtitle"`
+	SourceType string                 `json:"sourceType"`
+	Metadata   map[string]interface{} `json:"metadata"`
+}
+
+// attachmentSources maps Linear attachment source types to labels.
+var attachmentSources = map[string]string{
+	"slack":    "Slack",
+	"figma":    "Figma",
+	"sentry":   "Sentry",
+	"zendesk":  "Zendesk",
+	"intercom": "Intercom",
+	"front":    "Front",
+	"notion":   "Notion",
+	"loom":     "Loom",
+	"discord":  "Discord",
+	"gitlab":   "GitLab",
+	"github":   "GitHub",
+}
+
+// attachmentMetadataKeys lists the metadata worth showing for each source type.
+var attachmentMetadataKeys = map[string][]string{
+	"sentry": {"shortId", "level", "count", "userCount", "firstSeen", "lastSeen"},
+	"slack":  {"channelName", "messageAuthor"},
+}
+
+func (la *linearAttachment) source() string {
+	if l, ok := attachmentSources[strings.ToLower(la.SourceType)]; ok {
+		return l
+	}
+	if la.SourceType != "" {
+		return la.SourceType
+	}
+	return "Link"
+}
+
+func (la *linearAttachment) metadataSummary() string {
+	var a []string
+	for _, k := range attachmentMetadataKeys[strings.ToLower(la.SourceType)] {
+		v, ok := la.Metadata[k]
+		if !ok || v == nil || v == "" {
+			continue
+		}
+		a = append(a, fmt.Sprintf("%s: %v", k, v))
+	}
+	return strings.Join(a, ", ")
+}
+
+// formatAttachments returns a Markdown section listing the non GitHub attachments of the
+// issue grouped by source or "" if there are none. Unless $BYELINEAR_CUSTOMERS is show,
+// the support tickets behind customer requests are left out as they name the customer.
+func formatAttachments(liss *linearIssue) string {
+	needURLs := map[string]bool{}
+	if byelinearCustomers != customersShow {
+		for _, n := range liss.Needs.Nodes {
+			if n.Attachment != nil {
+				needURLs[n.Attachment.URL] = true
+			}
+		}
+	}
+	var atts []*linearAttachment
+	for _, att := range liss.Attachments.Nodes {
+		if _, ok := att.githubRef(); ok {
+			continue
+		}
+		if needURLs[att.URL] {
+			continue
+		}
+		atts = append(atts, att)
+	}
+	if len(atts) == 0 {
+		return ""
+	}
+	sort.SliceStable(atts, func(i, j int) bool {
+		return atts[i].source() < atts[j].source()
+	})
+
+	s := "### Attachments\n\n"
+	for _, att := range atts {
+		title := att.Title
+		if title == "" {
+			title = att.URL
+		}
+		s += fmt.Sprintf("- **%s** [%s](%s)", att.source(), title, att.URL)
+		if att.Subtitle != "" {
+			s += " — " + att.Subtitle
+		}
+		if md := att.metadataSummary(); md != "" {
+			s += " (" + md + ")"
+		}
+		s += "\n"
+	}
+	return s
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// corpusBackend persists the state and the fetched Linear issues. The JSON directory
+// backend is the default. See openCorpus.
+type corpusBackend interface {
+	readState() (*state, error)
+	// writeState persists s in full. It must be safe to call after every change.
+	writeState(s *state) error
+	// readIssue returns the issue JSON of ident. The error satisfies os.IsNotExist if
+	// ident is not in the corpus.
+	readIssue(ident string) ([]byte, error)
+	// writeIssue must be safe to call from concurrent goroutines for different issues.
+	writeIssue(ident string, b []byte) error
+	// issueIdents returns the identifiers of every stored issue whether or not it is in
+	// the state.
+	issueIdents() ([]string, error)
+	close() error
+}
+
+// corpus is opened by run.
+var corpus corpusBackend
+
+func openCorpus() (corpusBackend, error) {
+	switch byelinearCorpusBackend {
+	case "", "json":
+		return jsonCorpus{}, nil
+	case "bolt":
+		return openBoltCorpus()
+	default:
+		return nil, fmt.Errorf("$BYELINEAR_CORPUS_BACKEND must be json or bolt: %q", byelinearCorpusBackend)
+	}
+}
+
+// jsonCorpus stores the state in state.json and each issue in <identifier>.json in the
+// corpus directory.
+type jsonCorpus struct{}
+
+func (jsonCorpus) readState() (*state, error) {
+	sb, err := os.ReadFile(filepath.Join(byelinearCorpus, "state.json"))
+	if os.IsNotExist(err) {
+		return &state{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sb, err = migrateState(sb)
+	if err != nil {
+		return nil, err
+	}
+
+	var s *state
+	err = json.Unmarshal(sb, &s)
+	if err != nil {
+		return nil, fmt.Errorf("state.json is corrupt, restore it from a backup in %s: %w", byelinearCorpus, err)
+	}
+	return s, nil
+}
+
+func (jsonCorpus) writeState(s *state) error {
+	s.SchemaVersion = corpusSchemaVersion
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if !stateBackedUp {
+		err = backupFile(filepath.Join(byelinearCorpus, "state.json"), stateBackups)
+		if err != nil {
+			return err
+		}
+		stateBackedUp = true
+	}
+	return writeFile(filepath.Join(byelinearCorpus, "state.json"), b)
+}
+
+// readIssue upgrades the issue file in place if it is older than corpusSchemaVersion.
+func (jsonCorpus) readIssue(ident string) ([]byte, error) {
+	file := filepath.Join(byelinearCorpus, ident+".json")
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	b, migrated, err := migrateIssueJSON(ident, b)
+	if err != nil {
+		return nil, err
+	}
+	if migrated {
+		err = writeFile(file, b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func (jsonCorpus) writeIssue(ident string, b []byte) error {
+	return writeFile(filepath.Join(byelinearCorpus, ident+".json"), b)
+}
+
+// corpusFiles are the files in the corpus directory that are not issues.
+var corpusFiles = map[string]bool{
+	"state.json":      true,
+	"projects.json":   true,
+	"redactions.json": true,
+}
+
+func (jsonCorpus) issueIdents() ([]string, error) {
+	des, err := os.ReadDir(byelinearCorpus)
+	if err != nil {
+		return nil, err
+	}
+	var idents []string
+	for _, de := range des {
+		name := de.Name()
+		if de.IsDir() || filepath.Ext(name) != ".json" || corpusFiles[name] {
+			continue
+		}
+		ident := strings.TrimSuffix(name, ".json")
+		if _, err := identifierNumber(ident); err != nil {
+			continue
+		}
+		idents = append(idents, ident)
+	}
+	return idents, nil
+}
+
+func (jsonCorpus) close() error {
+	return nil
+}
+
+// index builds the indexes of s if they have not been built yet.
+func (s *state) index() {
+	if s.issueIndex != nil {
+		return
+	}
+	s.issueIndex = make(map[string]*issueState, len(s.Issues))
+	for _, iss := range s.Issues {
+		s.issueIndex[iss.Identifier] = iss
+	}
+	s.labelIndex = make(map[string]bool, len(s.Labels))
+	for _, l := range s.Labels {
+		s.labelIndex[l] = true
+	}
+	s.projectIndex = make(map[string]*projectState, len(s.Projects))
+	for _, p := range s.Projects {
+		s.projectIndex[p.Name] = p
+	}
+}
+
+func (s *state) issue(ident string) (*issueState, bool) {
+	s.index()
+	iss, ok := s.issueIndex[ident]
+	return iss, ok
+}
+
+// insertIssue adds iss after every issue created before it so that Issues stays in
+// creation order however filtered and unfiltered from-linear runs are mixed.
+func (s *state) insertIssue(iss *issueState) {
+	s.index()
+	i := len(s.Issues)
+	for i > 0 && s.Issues[i-1].CreatedAt.After(iss.CreatedAt) {
+		i--
+	}
+	s.Issues = append(s.Issues, nil)
+	copy(s.Issues[i+1:], s.Issues[i:])
+	s.Issues[i] = iss
+	s.issueIndex[iss.Identifier] = iss
+}
+
+func (s *state) addLabel(name string) {
+	s.index()
+	s.Labels = append(s.Labels, name)
+	s.labelIndex[name] = true
+}
+
+func (s *state) addProject(p *projectState) {
+	s.index()
+	s.Projects = append(s.Projects, p)
+	s.projectIndex[p.Name] = p
+}
+
+func (s *state) removeLabel(name string) {
+	for i, l := range s.Labels {
+		if l == name {
+			s.Labels = append(s.Labels[:i], s.Labels[i+1:]...)
+			break
+		}
+	}
+	if s.labelIndex != nil {
+		delete(s.labelIndex, name)
+	}
+}
+
+func (s *state) removeProject(name string) {
+	for i, p := range s.Projects {
+		if p.Name == name {
+			s.Projects = append(s.Projects[:i], s.Projects[i+1:]...)
+			break
+		}
+	}
+	if s.projectIndex != nil {
+		delete(s.projectIndex, name)
+	}
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Buckets of the bolt corpus.
+var (
+	boltMeta = []byte("meta")
+	// boltIssueOrder maps the big endian position of each issue in state.Issues to its
+	// identifier.
+	boltIssueOrder  = []byte("issue_order")
+	boltIssueStates = []byte("issue_states")
+	boltIssues      = []byte("issues")
+	boltLabels      = []byte("labels")
+	boltProjects    = []byte("projects")
+)
+
+// boltCorpus stores the corpus in corpus.db in the corpus directory with a bucket per
+// kind of record. writeState only writes the records that changed or were removed since
+// the last write in a single transaction.
+type boltCorpus struct {
+	db *bolt.DB
+
+	// The JSON of each record as last read or written.
+	issueStates map[string]string
+	labels      map[string]bool
+	projects    map[string]string
+	// order is the identifier of each issue in the issue_order bucket.
+	order        []string
+	linearCursor string
+}
+
+func openBoltCorpus() (*boltCorpus, error) {
+	name := filepath.Join(byelinearCorpus, "corpus.db")
+	_, err := os.Stat(name)
+	importJSON := os.IsNotExist(err)
+
+	db, err := bolt.Open(name, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	bc := &boltCorpus{
+		db:          db,
+		issueStates: make(map[string]string),
+		labels:      make(map[string]bool),
+		projects:    make(map[string]string),
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{boltMeta, boltIssueOrder, boltIssueStates, boltIssues, boltLabels, boltProjects} {
+			_, err := tx.CreateBucketIfNotExists(b)
+			if err != nil {
+				return err
+			}
+		}
+		// The GitHub numbers were duplicated into a bucket of their own that was never
+		// read. They are in the issue states.
+		if tx.Bucket([]byte("github_numbers")) != nil {
+			return tx.DeleteBucket([]byte("github_numbers"))
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if importJSON {
+		err = bc.importJSONCorpus()
+		if err != nil {
+			db.Close()
+			os.Remove(name)
+			return nil, err
+		}
+	}
+	return bc, nil
+}
+
+// importJSONCorpus copies a JSON directory corpus into a new bolt corpus.
+func (bc *boltCorpus) importJSONCorpus() error {
+	s, err := jsonCorpus{}.readState()
+	if err != nil {
+		return err
+	}
+	if len(s.Issues) == 0 && len(s.Labels) == 0 && len(s.Projects) == 0 {
+		return nil
+	}
+	log.Printf("importing state.json and issue files into corpus.db")
+	for _, iss := range s.Issues {
+		b, err := jsonCorpus{}.readIssue(iss.Identifier)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		err = bc.writeIssue(iss.Identifier, b)
+		if err != nil {
+			return err
+		}
+	}
+	return bc.writeState(s)
+}
+
+// readState assembles the records into the JSON of state.json so that the state
+// migrations of corpusMigrations apply to bolt corpora too.
+func (bc *boltCorpus) readState() (*state, error) {
+	version := corpusSchemaVersion
+	var issues, projects []json.RawMessage
+	labels := []string{}
+	var linearCursor string
+	err := bc.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(boltMeta).Get([]byte("schema_version")); v != nil {
+			var err error
+			version, err = strconv.Atoi(string(v))
+			if err != nil {
+				return err
+			}
+		}
+		linearCursor = string(tx.Bucket(boltMeta).Get([]byte("linear_cursor")))
+
+		states := tx.Bucket(boltIssueStates)
+		err := tx.Bucket(boltIssueOrder).ForEach(func(_, ident []byte) error {
+			v := states.Get(ident)
+			if v == nil {
+				return fmt.Errorf("corpus.db: %s has no state", ident)
+			}
+			issues = append(issues, append(json.RawMessage(nil), v...))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		err = tx.Bucket(boltLabels).ForEach(func(k, _ []byte) error {
+			labels = append(labels, string(k))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(boltProjects).ForEach(func(_, v []byte) error {
+			projects = append(projects, append(json.RawMessage(nil), v...))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(map[string]interface{}{
+		"schema_version": version,
+		"linear_cursor":  linearCursor,
+		"issues":         issues,
+		"labels":         labels,
+		"projects":       projects,
+	})
+	if err != nil {
+		return nil, err
+	}
+	b, migrated, err := migrateCorpusJSON("corpus.db", b, func(m *corpusMigration) func(map[string]interface{}) error {
+		return m.state
+	})
+	if err != nil {
+		return nil, err
+	}
+	var s *state
+	err = json.Unmarshal(b, &s)
+	if err != nil {
+		return nil, fmt.Errorf("corpus.db: %w", err)
+	}
+	s.SchemaVersion = corpusSchemaVersion
+	bc.linearCursor = linearCursor
+	if migrated {
+		// Leave the caches empty so that the next writeState rewrites every record.
+		log.Printf("migrating corpus.db to schema version %d", corpusSchemaVersion)
+		return s, nil
+	}
+
+	for i, iss := range s.Issues {
+		bc.issueStates[iss.Identifier] = string(issues[i])
+		bc.order = append(bc.order, iss.Identifier)
+	}
+	for _, l := range s.Labels {
+		bc.labels[l] = true
+	}
+	for i, p := range s.Projects {
+		bc.projects[p.Name] = string(projects[i])
+	}
+	return s, nil
+}
+
+func (bc *boltCorpus) writeState(s *state) error {
+	s.SchemaVersion = corpusSchemaVersion
+
+	issueStates := make(map[string]string)
+	labels := make(map[string]bool)
+	projects := make(map[string]string)
+	var removedLabels, removedProjects []string
+	err := bc.db.Update(func(tx *bolt.Tx) error {
+		err := tx.Bucket(boltMeta).Put([]byte("schema_version"), []byte(strconv.Itoa(corpusSchemaVersion)))
+		if err != nil {
+			return err
+		}
+		if s.LinearCursor != bc.linearCursor {
+			err = tx.Bucket(boltMeta).Put([]byte("linear_cursor"), []byte(s.LinearCursor))
+			if err != nil {
+				return err
+			}
+		}
+
+		// from-linear inserts issues in creation order so the order is rewritten from the
+		// first position that changed.
+		changed := false
+		for i, iss := range s.Issues {
+			if !changed && i < len(bc.order) && bc.order[i] == iss.Identifier {
+				continue
+			}
+			changed = true
+			var k [8]byte
+			binary.BigEndian.PutUint64(k[:], uint64(i))
+			err = tx.Bucket(boltIssueOrder).Put(k[:], []byte(iss.Identifier))
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, iss := range s.Issues {
+			b, err := json.Marshal(iss)
+			if err != nil {
+				return err
+			}
+			if bc.issueStates[iss.Identifier] == string(b) {
+				continue
+			}
+			err = tx.Bucket(boltIssueStates).Put([]byte(iss.Identifier), b)
+			if err != nil {
+				return err
+			}
+			issueStates[iss.Identifier] = string(b)
+		}
+
+		for _, l := range s.Labels {
+			if bc.labels[l] {
+				continue
+			}
+			err = tx.Bucket(boltLabels).Put([]byte(l), nil)
+			if err != nil {
+				return err
+			}
+			labels[l] = true
+		}
+
+		for l := range bc.labels {
+			if s.hasLabel(l) {
+				continue
+			}
+			err = tx.Bucket(boltLabels).Delete([]byte(l))
+			if err != nil {
+				return err
+			}
+			removedLabels = append(removedLabels, l)
+		}
+
+		for _, p := range s.Projects {
+			b, err := json.Marshal(p)
+			if err != nil {
+				return err
+			}
+			if bc.projects[p.Name] == string(b) {
+				continue
+			}
+			err = tx.Bucket(boltProjects).Put([]byte(p.Name), b)
+			if err != nil {
+				return err
+			}
+			projects[p.Name] = string(b)
+		}
+		for name := range bc.projects {
+			if _, ok := s.hasProject(name); ok {
+				continue
+			}
+			err = tx.Bucket(boltProjects).Delete([]byte(name))
+			if err != nil {
+				return err
+			}
+			removedProjects = append(removedProjects, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Only update the caches once the transaction has committed.
+	for k, v := range issueStates {
+		bc.issueStates[k] = v
+	}
+	for k := range labels {
+		bc.labels[k] = true
+	}
+	for k, v := range projects {
+		bc.projects[k] = v
+	}
+	for _, k := range removedLabels {
+		delete(bc.labels, k)
+	}
+	for _, k := range removedProjects {
+		delete(bc.projects, k)
+	}
+	bc.order = bc.order[:0]
+	for _, iss := range s.Issues {
+		bc.order = append(bc.order, iss.Identifier)
+	}
+	bc.linearCursor = s.LinearCursor
+	return nil
+}
+
+func (bc *boltCorpus) readIssue(ident string) ([]byte, error) {
+	var b []byte
+	err := bc.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltIssues).Get([]byte(ident))
+		if v == nil {
+			return &os.PathError{Op: "read", Path: "corpus.db/" + ident, Err: os.ErrNotExist}
+		}
+		b = append([]byte(nil), v...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	b, migrated, err := migrateIssueJSON(ident, b)
+	if err != nil {
+		return nil, err
+	}
+	if migrated {
+		err = bc.writeIssue(ident, b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func (bc *boltCorpus) writeIssue(ident string, b []byte) error {
+	return bc.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltIssues).Put([]byte(ident), b)
+	})
+}
+
+func (bc *boltCorpus) issueIdents() ([]string, error) {
+	var idents []string
+	err := bc.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltIssues).ForEach(func(k, _ []byte) error {
+			idents = append(idents, string(k))
+			return nil
+		})
+	})
+	return idents, err
+}
+
+func (bc *boltCorpus) close() error {
+	return bc.db.Close()
+}
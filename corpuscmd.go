@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const corpusUsage = `usage: byelinear corpus [ stats | show <identifier> | grep <regexp> | export-csv ]`
+
+// corpusCmd implements the corpus subcommand for inspecting the corpus. Every operation
+// except show applies $BYELINEAR_FILTER.
+func (s *state) corpusCmd(ctx context.Context, args []string) error {
+	if len(args) < 1 {
+		return errors.New(corpusUsage)
+	}
+	err := readLinearProjects()
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "stats":
+		return s.corpusStats()
+	case "show":
+		if len(args) != 2 {
+			return errors.New(corpusUsage)
+		}
+		return s.corpusShow(ctx, args[1])
+	case "grep":
+		if len(args) != 2 {
+			return errors.New(corpusUsage)
+		}
+		re, err := regexp.Compile(args[1])
+		if err != nil {
+			return err
+		}
+		return s.corpusGrep(re)
+	case "export-csv":
+		return s.corpusExportCSV()
+	default:
+		return errors.New(corpusUsage)
+	}
+}
+
+// eachIssue calls fn with every issue in the corpus that matches the filter.
+func (s *state) eachIssue(fn func(iss *issueState, liss *linearIssue) error) error {
+	for _, iss := range s.Issues {
+		if !filter.matchIdentifier(iss.Identifier) {
+			continue
+		}
+		liss, err := iss.linear()
+		if err != nil {
+			return err
+		}
+		if !filter.match(liss) {
+			continue
+		}
+		err = fn(iss, liss)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *state) corpusStats() error {
+	var total, exported int
+	teams := map[string]int{}
+	states := map[string]int{}
+	labels := map[string]int{}
+	projects := map[string]int{}
+	assignees := map[string]int{}
+	err := s.eachIssue(func(iss *issueState, liss *linearIssue) error {
+		total++
+		if iss.ExportedToGithub {
+			exported++
+		}
+		teams[liss.Team.Key]++
+		states[liss.State.Name]++
+		for _, l := range liss.labelsArr() {
+			labels[l]++
+		}
+		projects[liss.Project.Name]++
+		if liss.Assignee != nil {
+			assignees[formatLinearUser(liss.Assignee)]++
+		} else {
+			assignees[""]++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("issues: %d (%d exported to github)\n", total, exported)
+	printCounts("teams", teams)
+	printCounts("states", states)
+	printCounts("labels", labels)
+	printCounts("projects", projects)
+	printCounts("assignees", assignees)
+	return nil
+}
+
+// printCounts prints counts from most to least common.
+func printCounts(title string, counts map[string]int) {
+	var keys []string
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	fmt.Printf("\n%s:\n", title)
+	for _, k := range keys {
+		name := k
+		if name == "" {
+			name = "(none)"
+		}
+		fmt.Printf("\t%6d %s\n", counts[k], name)
+	}
+}
+
+func (s *state) corpusShow(ctx context.Context, ident string) error {
+	iss, ok := s.issue(ident)
+	if !ok {
+		return fmt.Errorf("%s is not in the corpus", ident)
+	}
+	liss, err := iss.linear()
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(liss, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("# linear\n\n%s\n", b)
+
+	if liss.Creator == nil {
+		fmt.Printf("\n# github\n\nskipped tutorial issue\n")
+		return nil
+	}
+	// The user clients decide whether the author is in the body.
+	err = loadGithubUserClients(ctx)
+	if err != nil {
+		return err
+	}
+	giss, err := fromLinearIssue(liss, withAuthor(liss))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\n# github\n\ntitle: %s\nstate: %s\n", giss.title, giss.state)
+	if iss.GithubNumber != 0 {
+		fmt.Printf("number: %d\n", iss.GithubNumber)
+	}
+	fmt.Printf("\n%s\n", giss.body)
+	for i, c := range giss.comments {
+		body, err := giss.commentBody(c, true, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n## comment %d\n\n%s\n", i, body)
+	}
+	if giss.timeline != "" {
+		fmt.Printf("\n## timeline\n\n%s\n", giss.timeline)
+	}
+	return nil
+}
+
+func (s *state) corpusGrep(re *regexp.Regexp) error {
+	return s.eachIssue(func(iss *issueState, liss *linearIssue) error {
+		var lines []string
+		grep := func(where, text string) {
+			for _, line := range strings.Split(text, "\n") {
+				if re.MatchString(line) {
+					lines = append(lines, fmt.Sprintf("\t%s: %s", where, strings.TrimSpace(line)))
+				}
+			}
+		}
+		grep("title", liss.Title)
+		grep("description", liss.Description)
+		for i, c := range liss.Comments.Nodes {
+			grep(fmt.Sprintf("comment %d", i), c.Body)
+		}
+		if len(lines) > 0 {
+			fmt.Printf("%s: %s\n%s\n", iss.Identifier, liss.Title, strings.Join(lines, "\n"))
+		}
+		return nil
+	})
+}
+
+func (s *state) corpusExportCSV() error {
+	w := csv.NewWriter(os.Stdout)
+	err := w.Write([]string{
+		"identifier", "title", "team", "state", "project", "priority", "creator", "assignee",
+		"labels", "comments", "created", "updated", "url", "exported", "github_number",
+	})
+	if err != nil {
+		return err
+	}
+	err = s.eachIssue(func(iss *issueState, liss *linearIssue) error {
+		var creator, assignee, githubNumber string
+		if liss.Creator != nil {
+			creator = liss.Creator.Email
+		}
+		if liss.Assignee != nil {
+			assignee = liss.Assignee.Email
+		}
+		if iss.GithubNumber != 0 {
+			githubNumber = strconv.Itoa(iss.GithubNumber)
+		}
+		return w.Write([]string{
+			iss.Identifier,
+			liss.Title,
+			liss.Team.Key,
+			liss.State.Name,
+			liss.Project.Name,
+			liss.PriorityLabel,
+			creator,
+			assignee,
+			strings.Join(liss.labelsArr(), ","),
+			strconv.Itoa(len(liss.Comments.Nodes)),
+			liss.CreatedAt.Format(time.RFC3339),
+			liss.UpdatedAt.Format(time.RFC3339),
+			liss.URL,
+			strconv.FormatBool(iss.ExportedToGithub),
+			githubNumber,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+)
+
+// Values of $BYELINEAR_CUSTOMERS.
+const (
+	customersRedact = "redact"
+	customersShow   = "show"
+	customersMirror = "mirror"
+)
+
+type linearCustomerNeed struct {
+	Body      string    `json:"body"`
+	Priority  float64   `json:"priority"`
+	CreatedAt time.Time `json:"createdAt"`
+	Customer  *struct {
+		Name string `json:"name"`
+	} `json:"customer"`
+	Attachment *struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+	} `json:"attachment"`
+}
+
+// triageLabel is added to issues in Linear's triage inbox.
+var triageLabel = &githubLabel{
+	name:  "triage",
+	color: "fbca04",
+	desc:  "Imported from the Linear triage inbox",
+}
+
+// formatCustomerNeeds returns the customer requests section of the issue body. Unless
+// $BYELINEAR_CUSTOMERS is show, customer names and requests are left out.
+func formatCustomerNeeds(liss *linearIssue) string {
+	needs := liss.Needs.Nodes
+	if len(needs) == 0 {
+		return ""
+	}
+	switch byelinearCustomers {
+	case customersShow:
+		return fmt.Sprintf("<details>\n<summary>Customer requests (%d)</summary>\n\n%s\n</details>", len(needs), formatCustomerNeedsTable(needs))
+	case customersMirror:
+		return fmt.Sprintf("### Customer requests\n\n%d customer requests are in a private issue.", len(needs))
+	default:
+		return fmt.Sprintf("### Customer requests\n\n%d customer requests were redacted.", len(needs))
+	}
+}
+
+func formatCustomerNeedsTable(needs []*linearCustomerNeed) string {
+	s := "customer | date | request | source\n| - | - | - | - |\n"
+	for _, n := range needs {
+		var customer, source string
+		if n.Customer != nil {
+			customer = n.Customer.Name
+		}
+		if n.Attachment != nil {
+			source = fmt.Sprintf("[%s](%s)", n.Attachment.Title, n.Attachment.URL)
+		}
+		s += fmt.Sprintf("%s | %s | %s | %s\n", customer, formatTime(n.CreatedAt), tableCell(n.Body), source)
+	}
+	return s
+}
+
+// tableCell makes s fit on a single Markdown table row.
+func tableCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// mirrorCustomerNeeds creates an issue with the customer requests of the Linear issue in
+// the private $BYELINEAR_CUSTOMERS_REPO that links to the exported issue.
+func mirrorCustomerNeeds(ctx context.Context, gc *github.Client, ident string, iss *githubIssue, issueURL string) error {
+	if byelinearCustomers != customersMirror || iss.customerNeeds == "" {
+		return nil
+	}
+	owner, repo, ok := strings.Cut(byelinearCustomersRepo, "/")
+	if !ok {
+		return fmt.Errorf("$BYELINEAR_CUSTOMERS_REPO must be org/repo: %q", byelinearCustomersRepo)
+	}
+	log.Printf("%s: creating customer requests mirror in %s", ident, byelinearCustomersRepo)
+	title := fmt.Sprintf("Customer requests for %s", iss.title)
+	body := fmt.Sprintf("Customer requests for %s\n\n%s", issueURL, iss.customerNeeds)
+	mirror, _, err := gc.Issues.Create(ctx, owner, repo, &github.IssueRequest{
+		Title: &title,
+		Body:  &body,
+	})
+	if err != nil {
+		return err
+	}
+	return recordOperation(&journalEntry{
+		Kind:   journalIssue,
+		Ident:  ident,
+		Repo:   byelinearCustomersRepo,
+		Number: mirror.GetNumber(),
+	})
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Names of the date fields created on each project.
+const (
+	dueDateFieldName   = "Due date"
+	startedFieldName   = "Started"
+	completedFieldName = "Completed"
+)
+
+type dateFieldInfo struct {
+	DueDateID   string `json:"due_date_id"`
+	StartedID   string `json:"started_id"`
+	CompletedID string `json:"completed_id"`
+}
+
+// ensureDateFields returns the IDs of the date fields of the project creating any that
+// do not exist.
+func ensureDateFields(ctx context.Context, hc *http.Client, projectID string) (*dateFieldInfo, error) {
+	fields, err := queryProjectFields(ctx, hc, projectID)
+	if err != nil {
+		return nil, err
+	}
+	di := &dateFieldInfo{}
+	for _, f := range []struct {
+		name string
+		id   *string
+	}{
+		{dueDateFieldName, &di.DueDateID},
+		{startedFieldName, &di.StartedID},
+		{completedFieldName, &di.CompletedID},
+	} {
+		id, ok := fields[f.name]
+		if !ok {
+			id, err = createProjectField(ctx, hc, projectID, f.name, "DATE")
+			if err != nil {
+				return nil, err
+			}
+		}
+		*f.id = id
+	}
+	return di, nil
+}
+
+// queryProjectFields returns the IDs of the project's fields by name.
+func queryProjectFields(ctx context.Context, hc *http.Client, projectID string) (map[string]string, error) {
+	queryString := `query($projectId: ID!) {
+		node(id: $projectId) {
+			... on ProjectV2 {
+				fields(first: 50) {
+					nodes {
+						... on ProjectV2FieldCommon {
+							id
+							name
+						}
+					}
+				}
+			}
+		}
+	}`
+	var queryResp struct {
+		Data struct {
+			Node struct {
+				Fields struct {
+					Nodes []struct {
+						ID   string `json:"id"`
+						Name string `json:"name"`
+					} `json:"nodes"`
+				} `json:"fields"`
+			} `json:"node"`
+		} `json:"data"`
+	}
+
+	qreq := &graphqlQuery{
+		Query:     queryString,
+		Variables: map[string]interface{}{"projectId": projectID},
+	}
+	err := doGithubQuery(ctx, hc, qreq, &queryResp)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]string)
+	for _, f := range queryResp.Data.Node.Fields.Nodes {
+		fields[f.Name] = f.ID
+	}
+	return fields, nil
+}
+
+func createProjectField(ctx context.Context, hc *http.Client, projectID, name, dataType string) (string, error) {
+	queryString := `mutation($projectId: ID!, $name: String!, $dataType: ProjectV2CustomFieldType!) {
+		createProjectV2Field(input: {projectId: $projectId, name: $name, dataType: $dataType}) {
+			projectV2Field {
+				... on ProjectV2FieldCommon {
+					id
+				}
+			}
+		}
+	}`
+	var queryResp struct {
+		Data struct {
+			CreateProjectV2Field struct {
+				ProjectV2Field struct {
+					ID string `json:"id"`
+				} `json:"projectV2Field"`
+			} `json:"createProjectV2Field"`
+		} `json:"data"`
+	}
+
+	qreq := &graphqlQuery{
+		Query:     queryString,
+		Variables: map[string]interface{}{"projectId": projectID, "name": name, "dataType": dataType},
+	}
+	err := doGithubQuery(ctx, hc, qreq, &queryResp)
+	if err != nil {
+		return "", err
+	}
+	return queryResp.Data.CreateProjectV2Field.ProjectV2Field.ID, nil
+}
+
+// setProjectIssueDates sets each date field of the project item that has a date.
+func setProjectIssueDates(ctx context.Context, hc *http.Client, projectID, itemID string, di *dateFieldInfo, iss *githubIssue) error {
+	for _, f := range []struct {
+		id   string
+		date string
+	}{
+		{di.DueDateID, iss.dueDate},
+		{di.StartedID, formatDate(iss.startedAt)},
+		{di.CompletedID, formatDate(iss.completedAt)},
+	} {
+		if f.id == "" || f.date == "" {
+			continue
+		}
+		err := setProjectItemDate(ctx, hc, projectID, itemID, f.id, f.date)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setProjectItemDate(ctx context.Context, hc *http.Client, projectID, itemID, fieldID, date string) error {
+	queryString := `mutation($projectId: ID!, $itemId: ID!, $fieldId: ID!, $date: Date) {
+		updateProjectV2ItemFieldValue(input: {projectId: $projectId, itemId: $itemId, fieldId: $fieldId, value: { date: $date }}) {
+			clientMutationId
+		}
+	}`
+	qreq := &graphqlQuery{
+		Query:     queryString,
+		Variables: map[string]interface{}{"projectId": projectID, "itemId": itemID, "fieldId": fieldID, "date": date},
+	}
+	return doGithubQuery(ctx, hc, qreq, nil)
+}
+
+// formatDate returns t as a GitHub project date or "" if t is nil.
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.In(time.Local).Format("2006-01-02")
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// toDocs writes the overview, updates and documents of every Linear project in the
+// corpus as Markdown files into $BYELINEAR_DOCS_DIR/<project-slug>/.
+func toDocs() error {
+	if byelinearDocsDir == "" {
+		byelinearDocsDir = filepath.Join("docs", "projects")
+	}
+
+	err := readLinearProjects()
+	if err != nil {
+		return err
+	}
+	if linearProjects == nil {
+		return fmt.Errorf("%s is missing: run from-linear first", filepath.Join(byelinearCorpus, "projects.json"))
+	}
+
+	for _, lp := range linearProjects {
+		dir := filepath.Join(byelinearDocsDir, slugify(lp.Name))
+		log.Printf("%s: writing to %s", lp.Name, dir)
+		err = os.MkdirAll(dir, 0755)
+		if err != nil {
+			return err
+		}
+
+		err = writeDoc(filepath.Join(dir, "README.md"), fmt.Sprintf("# %s\n\n%s", lp.Name, lp.overview()))
+		if err != nil {
+			return err
+		}
+		if updates := lp.updatesMarkdown(); updates != "" {
+			err = writeDoc(filepath.Join(dir, "updates.md"), fmt.Sprintf("# %s updates\n%s", lp.Name, updates))
+			if err != nil {
+				return err
+			}
+		}
+		for _, d := range lp.Documents.Nodes {
+			md := fmt.Sprintf(`# %s
+
+field | value
+| - | - |
+author | %s
+created | %s
+updated | %s
+
+%s
+`,
+				d.Title,
+				formatLinearUser(d.Creator),
+				formatTime(d.CreatedAt),
+				formatTime(d.UpdatedAt),
+				d.Content,
+			)
+			err = writeDoc(filepath.Join(dir, d.fileName()), md)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	log.Printf("all linear projects written to %s", byelinearDocsDir)
+	return nil
+}
+
+// writeDoc writes md to name after applying $BYELINEAR_REDACT as to-github does to the
+// project READMEs. Files matching a block rule are not written.
+func writeDoc(name, md string) error {
+	if redactor != nil {
+		rs, blocked := redactor.redactString(name, &md)
+		if blocked {
+			log.Printf("%s: skipped blocked file", name)
+			return nil
+		}
+		if len(rs) > 0 {
+			log.Printf("%s: redacted %d matches", name, len(rs))
+		}
+	}
+	return os.WriteFile(name, []byte(md), 0644)
+}
+
+// fileName returns the name of the Markdown file of d. The start of the document ID
+// keeps documents with the same title from overwriting each other or README.md and
+// updates.md.
+func (d *linearDocument) fileName() string {
+	id := d.ID
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	slug := slugify(d.Title)
+	if slug == "" {
+		slug = "document"
+	}
+	return fmt.Sprintf("%s-%s.md", slug, id)
+}
+
+// slugify returns s lowercased with runs of anything but letters and digits replaced by
+// a dash.
+func slugify(s string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+			dash = false
+		} else if !dash && sb.Len() > 0 {
+			sb.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "-")
+}
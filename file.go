@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// stateBackups is the number of previous state.json files kept as state.json.1 through
+// state.json.N from newest to oldest.
+const stateBackups = 5
+
+// stateBackedUp is set once state.json has been backed up by this run. state.json is
+// written after every step of to-github so it is only backed up before the first write
+// of each run.
+var stateBackedUp bool
+
+// writeFile atomically replaces name with b. b is written and synced to a temporary
+// file in the same directory that is then renamed over name so that a crash never leaves
+// a truncated file behind.
+func writeFile(name string, b []byte) (err error) {
+	dir := filepath.Dir(name)
+	f, err := os.CreateTemp(dir, filepath.Base(name)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	_, err = f.Write(b)
+	if err != nil {
+		return err
+	}
+	err = f.Chmod(0644)
+	if err != nil {
+		return err
+	}
+	err = f.Sync()
+	if err != nil {
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+	err = os.Rename(f.Name(), name)
+	if err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// syncDir persists renames in dir. Not every platform supports syncing a directory so
+// errors are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
+
+// backupFile rotates name.1 through name.n and copies name to name.1. It does nothing if
+// name does not exist.
+func backupFile(name string, n int) error {
+	_, err := os.Stat(name)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for i := n - 1; i >= 1; i-- {
+		err = os.Rename(name+"."+strconv.Itoa(i), name+"."+strconv.Itoa(i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return copyFile(name, name+".1")
+}
+
+func copyFile(src, dst string) error {
+	sf, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer sf.Close()
+	b, err := io.ReadAll(sf)
+	if err != nil {
+		return err
+	}
+	return writeFile(dst, b)
+}
+
+// lockCorpus creates the lock file in the corpus so that two byelinear processes cannot
+// drive the same corpus at once. The returned function removes it.
+func lockCorpus() (func(), error) {
+	err := os.MkdirAll(byelinearCorpus, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	name := filepath.Join(byelinearCorpus, "lock")
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, os.ErrExist) {
+		pid, _ := os.ReadFile(name)
+		return nil, fmt.Errorf("%s is locked by byelinear process %s: delete %s if it is no longer running", byelinearCorpus, strings.TrimSpace(string(pid)), name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, err = f.WriteString(strconv.Itoa(os.Getpid()))
+	if err != nil {
+		f.Close()
+		os.Remove(name)
+		return nil, err
+	}
+	err = f.Close()
+	if err != nil {
+		os.Remove(name)
+		return nil, err
+	}
+	return func() {
+		os.Remove(name)
+	}, nil
+}
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// issueFilter is parsed from $BYELINEAR_FILTER. See the README for the syntax.
+//
+// As much of the filter as possible is pushed into the filter argument of the Linear
+// issues query. match then applies the whole filter locally so that the same filter can
+// be used on the corpus with to-github.
+type issueFilter struct {
+	teams         []string
+	excludeTeams  []string
+	states        []string
+	excludeStates []string
+	labels        []string
+	excludeLabels []string
+	projects      []string
+	assignees     []string
+	identifiers   []string
+	numbers       []int
+
+	createdAfter  time.Time
+	createdBefore time.Time
+	updatedAfter  time.Time
+	updatedBefore time.Time
+
+	// archived is one of "", "true" or "false". "" matches both archived and active
+	// issues.
+	archived string
+}
+
+func parseIssueFilter(s string) (*issueFilter, error) {
+	f := &issueFilter{}
+	for _, term := range splitQuoted(s, ' ') {
+		if term == "" {
+			continue
+		}
+		err := f.parseTerm(term)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter term %q: %w", term, err)
+		}
+	}
+	return f, nil
+}
+
+func (f *issueFilter) parseTerm(term string) error {
+	i := strings.IndexAny(term, "=<>")
+	if i <= 0 {
+		return fmt.Errorf("expected key=value, key>=date or key<date")
+	}
+	key := term[:i]
+	op := term[i : i+1]
+	val := term[i+1:]
+	if strings.HasPrefix(val, "=") {
+		op += "="
+		val = val[1:]
+	}
+
+	exclude := strings.HasPrefix(key, "-")
+	key = strings.TrimPrefix(key, "-")
+
+	switch key {
+	case "created", "updated":
+		if exclude {
+			return fmt.Errorf("%s cannot be excluded", key)
+		}
+		t, err := parseFilterDate(unquote(val))
+		if err != nil {
+			return err
+		}
+		var after, before *time.Time
+		if key == "created" {
+			after, before = &f.createdAfter, &f.createdBefore
+		} else {
+			after, before = &f.updatedAfter, &f.updatedBefore
+		}
+		switch op {
+		case ">=":
+			*after = t
+		case "<":
+			*before = t
+		default:
+			return fmt.Errorf("%s requires >= or <", key)
+		}
+		return nil
+	}
+
+	if op != "=" {
+		return fmt.Errorf("%s requires =", key)
+	}
+	vals := splitQuoted(val, ',')
+	for i := range vals {
+		vals[i] = unquote(vals[i])
+	}
+
+	switch key {
+	case "team":
+		if exclude {
+			f.excludeTeams = append(f.excludeTeams, vals...)
+		} else {
+			f.teams = append(f.teams, vals...)
+		}
+	case "state":
+		if exclude {
+			f.excludeStates = append(f.excludeStates, vals...)
+		} else {
+			f.states = append(f.states, vals...)
+		}
+	case "label":
+		if exclude {
+			f.excludeLabels = append(f.excludeLabels, vals...)
+		} else {
+			f.labels = append(f.labels, vals...)
+		}
+	case "project":
+		if exclude {
+			return fmt.Errorf("project cannot be excluded")
+		}
+		f.projects = append(f.projects, vals...)
+	case "assignee":
+		if exclude {
+			return fmt.Errorf("assignee cannot be excluded")
+		}
+		f.assignees = append(f.assignees, vals...)
+	case "id":
+		if exclude {
+			return fmt.Errorf("id cannot be excluded")
+		}
+		for _, ident := range vals {
+			n, err := identifierNumber(ident)
+			if err != nil {
+				return err
+			}
+			f.identifiers = append(f.identifiers, ident)
+			f.numbers = append(f.numbers, n)
+		}
+	case "archived":
+		if exclude || len(vals) != 1 {
+			return fmt.Errorf("archived must be one of true, false or any")
+		}
+		switch vals[0] {
+		case "true", "false":
+			f.archived = vals[0]
+		case "any":
+			f.archived = ""
+		default:
+			return fmt.Errorf("archived must be one of true, false or any")
+		}
+	default:
+		return fmt.Errorf("unknown key %q", key)
+	}
+	return nil
+}
+
+func parseFilterDate(s string) (time.Time, error) {
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func identifierNumber(ident string) (int, error) {
+	i := strings.LastIndexByte(ident, '-')
+	if i <= 0 {
+		return 0, fmt.Errorf("invalid identifier %q: expected TEAM-NUMBER", ident)
+	}
+	n, err := strconv.Atoi(ident[i+1:])
+	if err != nil {
+		return 0, fmt.Errorf("invalid identifier %q: expected TEAM-NUMBER", ident)
+	}
+	return n, nil
+}
+
+// splitQuoted splits s by sep except within double quotes.
+func splitQuoted(s string, sep byte) []string {
+	var a []string
+	var quoted bool
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case sep:
+			if !quoted {
+				a = append(a, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(a, s[start:])
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// linear returns the filter argument for the Linear issues query and whether archived
+// issues should be included. Names are compared ignoring case like match does.
+func (f *issueFilter) linear() (map[string]interface{}, bool) {
+	var and []interface{}
+	and = append(and, foldComparators(f.teams, f.excludeTeams, func(sc interface{}) interface{} {
+		return map[string]interface{}{"team": map[string]interface{}{"key": sc}}
+	})...)
+	and = append(and, foldComparators(f.states, f.excludeStates, func(sc interface{}) interface{} {
+		return map[string]interface{}{"state": map[string]interface{}{"name": sc}}
+	})...)
+	and = append(and, foldComparators(f.labels, nil, func(sc interface{}) interface{} {
+		return map[string]interface{}{"labels": map[string]interface{}{
+			"some": map[string]interface{}{"name": sc},
+		}}
+	})...)
+	and = append(and, foldComparators(f.projects, nil, func(sc interface{}) interface{} {
+		return map[string]interface{}{"project": map[string]interface{}{"name": sc}}
+	})...)
+	and = append(and, foldComparators(f.assigneeEmails(), nil, func(sc interface{}) interface{} {
+		return map[string]interface{}{"assignee": map[string]interface{}{"email": sc}}
+	})...)
+
+	lf := map[string]interface{}{}
+	if len(and) > 0 {
+		lf["and"] = and
+	}
+	if len(f.numbers) > 0 {
+		lf["number"] = map[string]interface{}{"in": f.numbers}
+	}
+	if dc := dateComparator(f.createdAfter, f.createdBefore); dc != nil {
+		lf["createdAt"] = dc
+	}
+	if dc := dateComparator(f.updatedAfter, f.updatedBefore); dc != nil {
+		lf["updatedAt"] = dc
+	}
+	return lf, f.archived != "false"
+}
+
+// assigneeEmails returns the assignees to push into the Linear query. Assignees may be
+// given as GitHub logins too in which case they can only be matched locally.
+func (f *issueFilter) assigneeEmails() []string {
+	for _, a := range f.assignees {
+		if !strings.Contains(a, "@") {
+			return nil
+		}
+	}
+	return f.assignees
+}
+
+// foldComparators returns the filters matching any of in and none of nin ignoring case.
+// Linear has no case insensitive in or nin so each value gets its own comparator. field
+// wraps a comparator into the filter of the compared field.
+func foldComparators(in, nin []string, field func(sc interface{}) interface{}) []interface{} {
+	var filters []interface{}
+	if len(in) > 0 {
+		var or []interface{}
+		for _, v := range in {
+			or = append(or, field(map[string]interface{}{"eqIgnoreCase": v}))
+		}
+		filters = append(filters, map[string]interface{}{"or": or})
+	}
+	for _, v := range nin {
+		filters = append(filters, field(map[string]interface{}{"neqIgnoreCase": v}))
+	}
+	return filters
+}
+
+func dateComparator(after, before time.Time) map[string]interface{} {
+	if after.IsZero() && before.IsZero() {
+		return nil
+	}
+	dc := map[string]interface{}{}
+	if !after.IsZero() {
+		dc["gte"] = after.UTC().Format(time.RFC3339)
+	}
+	if !before.IsZero() {
+		dc["lt"] = before.UTC().Format(time.RFC3339)
+	}
+	return dc
+}
+
+func (f *issueFilter) match(liss *linearIssue) bool {
+	if len(f.identifiers) > 0 && !containsString(f.identifiers, liss.Identifier) {
+		return false
+	}
+	if len(f.teams) > 0 && !containsFold(f.teams, liss.Team.Key) {
+		return false
+	}
+	if containsFold(f.excludeTeams, liss.Team.Key) {
+		return false
+	}
+	if len(f.states) > 0 && !containsFold(f.states, liss.State.Name) {
+		return false
+	}
+	if containsFold(f.excludeStates, liss.State.Name) {
+		return false
+	}
+	if len(f.projects) > 0 && !containsFold(f.projects, liss.Project.Name) {
+		return false
+	}
+	if len(f.labels) > 0 {
+		var ok bool
+		for _, l := range liss.labelsArr() {
+			if containsFold(f.labels, l) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	for _, l := range liss.labelsArr() {
+		if containsFold(f.excludeLabels, l) {
+			return false
+		}
+	}
+	if len(f.assignees) > 0 {
+		if liss.Assignee == nil {
+			return false
+		}
+		if !containsFold(f.assignees, liss.Assignee.Email) && !containsFold(f.assignees, emailsToGithubMap[liss.Assignee.Email]) {
+			return false
+		}
+	}
+	if !f.createdAfter.IsZero() && liss.CreatedAt.Before(f.createdAfter) {
+		return false
+	}
+	if !f.createdBefore.IsZero() && !liss.CreatedAt.Before(f.createdBefore) {
+		return false
+	}
+	if !f.updatedAfter.IsZero() && liss.UpdatedAt.Before(f.updatedAfter) {
+		return false
+	}
+	if !f.updatedBefore.IsZero() && !liss.UpdatedAt.Before(f.updatedBefore) {
+		return false
+	}
+	switch f.archived {
+	case "true":
+		if liss.ArchivedAt == nil {
+			return false
+		}
+	case "false":
+		if liss.ArchivedAt != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// matchIdentifier reports whether the filter could match an issue with the given
+// identifier. It allows skipping issues in the corpus without reading them.
+func (f *issueFilter) matchIdentifier(ident string) bool {
+	if len(f.identifiers) > 0 && !containsString(f.identifiers, ident) {
+		return false
+	}
+	if len(f.numbers) > 0 {
+		n, err := identifierNumber(ident)
+		if err != nil {
+			return false
+		}
+		var ok bool
+		for _, n2 := range f.numbers {
+			if n == n2 {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(a []string, s string) bool {
+	for _, s2 := range a {
+		if s == s2 {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(a []string, s string) bool {
+	for _, s2 := range a {
+		if strings.EqualFold(s, s2) {
+			return true
+		}
+	}
+	return false
+}
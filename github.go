@@ -7,16 +7,77 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-github/v47/github"
 )
 
-func (s *state) exportToGithub(ctx context.Context, gc *github.Client, ident string, iss *githubIssue) (string, error) {
+// githubExport is an issue being exported. createGithubIssue runs for each issue in order
+// on one goroutine while finishGithubIssue runs the follow-ups of created issues on a
+// pool of workers.
+type githubExport struct {
+	state *issueState
+	iss   *githubIssue
+
+	number int
+	nodeID string
+	url    string
+
+	// step is the number of follow-ups done so that a failed finishGithubIssue resumes
+	// where it left off when retried. It is saved as GithubStep.
+	step int
+	// commentURLs maps Linear comment IDs to the URLs of the created GitHub comments for
+	// linking replies. commentIDs maps them to the IDs for creating their reactions.
+	commentURLs map[string]string
+	commentIDs  map[string]int64
+}
+
+func (e *githubExport) ident() string {
+	return e.state.Identifier
+}
+
+// createGithubIssue creates the issue. With $BYELINEAR_GITHUB_IMPORT the comments are
+// created along with it.
+func (s *state) createGithubIssue(ctx context.Context, gc *github.Client, e *githubExport) error {
 	ctx, cancel := context.WithTimeout(ctx, time.Minute*2)
 	defer cancel()
 
+	if byelinearGithubImport != "" {
+		return s.importToGithub(ctx, gc, e)
+	}
+
+	ident, iss := e.ident(), e.iss
+	err := s.ensureLabels(ctx, gc, ident, iss)
+	if err != nil {
+		return err
+	}
+	issReq := iss.issueRequest()
+	var giss *github.Issue
+	if ugc := githubUserClient(iss.author); ugc != nil {
+		log.Printf("%s: creating as @%s", ident, iss.author)
+		giss, _, err = ugc.Issues.Create(ctx, orgName, repoName, issReq)
+	} else {
+		log.Printf("%s: creating", ident)
+		giss, _, err = gc.Issues.Create(ctx, orgName, repoName, issReq)
+	}
+	if err != nil {
+		return err
+	}
+	e.number = giss.GetNumber()
+	e.nodeID = giss.GetNodeID()
+	e.url = giss.GetHTMLURL()
+	return recordOperation(&journalEntry{
+		Kind:   journalIssue,
+		Ident:  ident,
+		Repo:   orgName + "/" + repoName,
+		Number: e.number,
+	})
+}
+
+func (iss *githubIssue) issueRequest() *github.IssueRequest {
 	issReq := &github.IssueRequest{
 		Title:    &iss.title,
 		Assignee: &iss.assignee,
@@ -27,71 +88,297 @@ func (s *state) exportToGithub(ctx context.Context, gc *github.Client, ident str
 	}
 	for _, l := range iss.labels {
 		*issReq.Labels = append(*issReq.Labels, l.name)
-
-		log.Printf("%s: ensuring label: %s", ident, l.name)
-		if !s.hasLabel(l.name) {
-			color := strings.TrimPrefix(l.color, "#")
-			err := ensureLabel(ctx, gc, l.name, color, l.desc)
-			if err != nil {
-				return "", err
-			}
-			s.Labels = append(s.Labels, l.name)
-		}
-	}
-	log.Printf("%s: creating", ident)
-	giss, _, err := gc.Issues.Create(ctx, orgName, repoName, issReq)
-	if err != nil {
-		return "", err
 	}
-	if iss.state == "Done" || iss.state == "Canceled" {
+	if iss.closed() {
 		issReq.State = github.String("closed")
 		issReq.StateReason = github.String("completed")
 		if iss.state == "Canceled" {
 			issReq.StateReason = github.String("not_planned")
 		}
-		_, _, err = gc.Issues.Edit(ctx, orgName, repoName, *giss.Number, issReq)
+	}
+	return issReq
+}
+
+func (iss *githubIssue) closed() bool {
+	return iss.state == "Done" || iss.state == "Canceled"
+}
+
+// finishGithubIssue runs the follow-ups of the created issue in order: closing it,
+// comments and their reactions, reactions, the customer requests mirror and adding it to
+// its project. The reactions of a comment are a step of their own so that a failed
+// reaction does not create the comment again.
+func (s *state) finishGithubIssue(ctx context.Context, gc *github.Client, e *githubExport) error {
+	ident, iss := e.ident(), e.iss
+	var steps []func(ctx context.Context) error
+	if byelinearGithubImport == "" {
+		// GitHub silently drops the labels and assignee if the author lacks push access so
+		// they are always set again with gc.
+		if iss.closed() || githubUserClient(iss.author) != nil {
+			steps = append(steps, func(ctx context.Context) error {
+				_, _, err := gc.Issues.Edit(ctx, orgName, repoName, e.number, iss.issueRequest())
+				return err
+			})
+		}
+		for i, c := range iss.comments {
+			i, c := i, c
+			steps = append(steps, func(ctx context.Context) error {
+				return e.createComment(ctx, gc, i, c)
+			})
+			if len(c.reactions) > 0 {
+				steps = append(steps, func(ctx context.Context) error {
+					id, ok := e.commentIDs[c.id]
+					if !ok {
+						log.Printf("%s: skipped reactions of comment %d created by an earlier run", ident, i)
+						return nil
+					}
+					return createCommentReactions(ctx, gc, ident, id, c.reactions)
+				})
+			}
+		}
+		if iss.closedBy != "" {
+			steps = append(steps, func(ctx context.Context) error {
+				log.Printf("%s: creating closed by comment", ident)
+				return e.createBotComment(ctx, gc, iss.closedBy)
+			})
+		}
+		if iss.timeline != "" {
+			steps = append(steps, func(ctx context.Context) error {
+				log.Printf("%s: creating timeline comment", ident)
+				return e.createBotComment(ctx, gc, iss.timeline)
+			})
+		}
+	}
+	// The import API has no reactions and the IDs of the imported comments are unknown so
+	// only the issue's reactions can be created.
+	steps = append(steps,
+		func(ctx context.Context) error {
+			return createIssueReactions(ctx, gc, ident, e.number, iss.reactions)
+		},
+		func(ctx context.Context) error {
+			return mirrorCustomerNeeds(ctx, gc, ident, iss, e.url)
+		},
+		func(ctx context.Context) error {
+			return s.addToProject(ctx, gc, ident, iss, e.nodeID)
+		},
+	)
+
+	for e.step < len(steps) {
+		ctx, cancel := context.WithTimeout(ctx, time.Minute*2)
+		err := steps[e.step](ctx)
+		cancel()
+		if err != nil {
+			return err
+		}
+		e.step++
+		s.mu.Lock()
+		e.state.GithubStep = e.step
+		s.mu.Unlock()
+		err = writeState(s)
 		if err != nil {
-			return "", err
+			return err
 		}
 	}
-	for i, c := range iss.comments {
-		log.Printf("%s: creating comment %d", ident, i)
-		_, _, err = gc.Issues.CreateComment(ctx, orgName, repoName, *giss.Number, &github.IssueComment{
-			Body: &c,
+	return nil
+}
+
+// resume prepares the export of an issue created by an earlier run that stopped before
+// its follow-ups were done.
+func (e *githubExport) resume(ctx context.Context, gc *github.Client) error {
+	e.number = e.state.GithubNumber
+	e.step = e.state.GithubStep
+	for {
+		giss, _, err := gc.Issues.Get(ctx, orgName, repoName, e.number)
+		if err == nil {
+			e.nodeID = giss.GetNodeID()
+			e.url = giss.GetHTMLURL()
+			return nil
+		}
+		log.Printf("%s: failed to get #%d (retrying in 5 minutes): %v", e.ident(), e.number, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Minute * 5):
+		}
+	}
+}
+
+func (e *githubExport) createComment(ctx context.Context, gc *github.Client, i int, c *githubComment) error {
+	ident, iss := e.ident(), e.iss
+	if e.commentURLs == nil {
+		e.commentURLs = make(map[string]string)
+		e.commentIDs = make(map[string]int64)
+	}
+	var gcomment *github.IssueComment
+	var body string
+	var err error
+	if ugc := githubUserClient(c.author); ugc != nil {
+		log.Printf("%s: creating comment %d as @%s", ident, i, c.author)
+		body, err = iss.commentBody(c, false, e.commentURLs)
+		if err != nil {
+			return err
+		}
+		gcomment, _, err = ugc.Issues.CreateComment(ctx, orgName, repoName, e.number, &github.IssueComment{
+			Body: &body,
 		})
+	} else {
+		log.Printf("%s: creating comment %d", ident, i)
+		body, err = iss.commentBody(c, true, e.commentURLs)
 		if err != nil {
-			return "", err
+			return err
 		}
+		gcomment, _, err = gc.Issues.CreateComment(ctx, orgName, repoName, e.number, &github.IssueComment{
+			Body: &body,
+		})
 	}
-	if iss.project != nil {
-		log.Printf("%s: ensuring project: %s", ident, iss.project.name)
-		p, ok := s.hasProject(iss.project.name)
+	if err != nil {
+		return err
+	}
+	err = recordComment(ident, e.number, gcomment)
+	if err != nil {
+		return err
+	}
+	e.commentURLs[c.id] = gcomment.GetHTMLURL()
+	e.commentIDs[c.id] = gcomment.GetID()
+	return nil
+}
+
+// createBotComment creates a comment generated by byelinear rather than from Linear.
+func (e *githubExport) createBotComment(ctx context.Context, gc *github.Client, body string) error {
+	gcomment, _, err := gc.Issues.CreateComment(ctx, orgName, repoName, e.number, &github.IssueComment{
+		Body: &body,
+	})
+	if err != nil {
+		return err
+	}
+	return recordComment(e.ident(), e.number, gcomment)
+}
+
+func recordComment(ident string, number int, gcomment *github.IssueComment) error {
+	return recordOperation(&journalEntry{
+		Kind:   journalComment,
+		Ident:  ident,
+		Repo:   orgName + "/" + repoName,
+		Number: number,
+		ID:     gcomment.GetID(),
+	})
+}
+
+func (s *state) ensureLabels(ctx context.Context, gc *github.Client, ident string, iss *githubIssue) error {
+	for _, l := range iss.labels {
+		log.Printf("%s: ensuring label: %s", ident, l.name)
+		s.mu.Lock()
+		ok := s.hasLabel(l.name)
+		s.mu.Unlock()
 		if !ok {
-			pID, pnum, err := ensureProject(ctx, gc.Client(), iss.project.name, iss.project.desc)
-			if err != nil {
-				return "", err
-			}
-			si, err := queryStatusField(ctx, gc.Client(), pnum)
+			color := strings.TrimPrefix(l.color, "#")
+			err := ensureLabel(ctx, gc, l.name, color, l.desc)
 			if err != nil {
-				return "", err
+				return err
 			}
-			p = &projectState{
-				Name:            iss.project.name,
-				ID:              pID,
-				StatusFieldInfo: si,
-			}
-			s.Projects = append(s.Projects, p)
+			s.mu.Lock()
+			s.addLabel(l.name)
+			s.mu.Unlock()
 		}
-		itemID, err := addIssueToProject(ctx, gc.Client(), p.ID, *giss.NodeID)
+	}
+	return nil
+}
+
+func (s *state) addToProject(ctx context.Context, gc *github.Client, ident string, iss *githubIssue, nodeID string) error {
+	if iss.project == nil {
+		return nil
+	}
+	log.Printf("%s: ensuring project: %s", ident, iss.project.name)
+	p, err := s.ensureGithubProject(ctx, gc, iss.project)
+	if err != nil {
+		return err
+	}
+	itemID, err := addIssueToProject(ctx, gc.Client(), p.ID, nodeID)
+	if err != nil {
+		return err
+	}
+	err = setProjectIssueStatus(ctx, gc.Client(), p.ID, itemID, p.StatusFieldInfo, iss.state)
+	if err != nil {
+		return err
+	}
+	if p.MilestoneFieldInfo != nil && p.MilestoneFieldInfo.OptionIDs[iss.milestone] != "" {
+		err = setProjectItemOption(ctx, gc.Client(), p.ID, itemID, p.MilestoneFieldInfo.ID, p.MilestoneFieldInfo.OptionIDs[iss.milestone])
+		if err != nil {
+			return err
+		}
+	}
+	return setProjectIssueDates(ctx, gc.Client(), p.ID, itemID, p.DateFieldInfo, iss)
+}
+
+// ensureGithubProject returns a copy of the state of the project, creating the project
+// if needed. The project is locked throughout so that concurrent workers create it once
+// but the state is only locked to read and update it.
+func (s *state) ensureGithubProject(ctx context.Context, gc *github.Client, gp *githubProject) (*projectState, error) {
+	defer s.lockProject(gp.name)()
+
+	s.mu.Lock()
+	p, ok := s.hasProject(gp.name)
+	s.mu.Unlock()
+	if !ok {
+		pID, pnum, err := ensureProject(ctx, gc.Client(), gp.name, gp.desc)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
-		err = setProjectIssueStatus(ctx, gc.Client(), p.ID, itemID, p.StatusFieldInfo, iss.state)
+		si, err := queryStatusField(ctx, gc.Client(), pnum)
 		if err != nil {
-			return "", err
+			return nil, err
+		}
+		np := &projectState{
+			Name:            gp.name,
+			ID:              pID,
+			StatusFieldInfo: si,
+		}
+		if gp.readme != "" {
+			err = updateProjectReadme(ctx, gc.Client(), pID, gp.readme)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(gp.milestones) > 0 {
+			np.MilestoneFieldInfo, err = ensureMilestoneField(ctx, gc.Client(), pID, gp.milestones)
+			if err != nil {
+				return nil, err
+			}
 		}
+		s.mu.Lock()
+		s.addProject(np)
+		s.mu.Unlock()
+		p = np
 	}
-	return giss.GetHTMLURL(), nil
+	if p.DateFieldInfo == nil {
+		di, err := ensureDateFields(ctx, gc.Client(), p.ID)
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		p.DateFieldInfo = di
+		s.mu.Unlock()
+	}
+	s.mu.Lock()
+	cp := *p
+	s.mu.Unlock()
+	return &cp, nil
+}
+
+// lockProject locks the project with the given name and returns the function unlocking
+// it.
+func (s *state) lockProject(name string) func() {
+	s.mu.Lock()
+	if s.projectMus == nil {
+		s.projectMus = make(map[string]*sync.Mutex)
+	}
+	pmu, ok := s.projectMus[name]
+	if !ok {
+		pmu = &sync.Mutex{}
+		s.projectMus[name] = pmu
+	}
+	s.mu.Unlock()
+	pmu.Lock()
+	return pmu.Unlock
 }
 
 type githubLabel struct {
@@ -101,76 +388,171 @@ type githubLabel struct {
 }
 
 type githubIssue struct {
-	title    string
-	assignee string
-	body     string
-	state    string
-	project  *githubProject
-	labels   []*githubLabel
-	comments []string
+	title     string
+	author    string
+	assignee  string
+	body      string
+	state     string
+	createdAt time.Time
+	closedAt  *time.Time
+	// dueDate is formatted as YYYY-MM-DD.
+	dueDate     string
+	startedAt   *time.Time
+	completedAt *time.Time
+	project     *githubProject
+	milestone   string
+	labels      []*githubLabel
+	comments    []*githubComment
+	reactions   []*githubReaction
+	// closedBy is a comment referencing the merged pull requests if $BYELINEAR_CLOSED_BY
+	// is set.
+	closedBy string
+	// timeline is the collapsed Linear history comment if $BYELINEAR_HISTORY is set.
+	timeline string
+	// customerNeeds is the unredacted table of customer requests for the private mirror.
+	customerNeeds string
+}
+
+type githubComment struct {
+	id        string
+	parentID  string
+	url       string
+	author    string
+	createdAt time.Time
+	editedAt  *time.Time
+	text      string
+	linear    *linearComment
+	// reactionsSummary is the count of each Linear reaction.
+	reactionsSummary string
+	reactions        []*githubReaction
+}
+
+// commentBody renders c. The author is left out of the table when the comment is created
+// by the author. Replies quote their parent and link to it in urls if it was created
+// on GitHub and otherwise to it on Linear.
+func (iss *githubIssue) commentBody(c *githubComment, withAuthor bool, urls map[string]string) (string, error) {
+	d := &commentTemplateData{
+		Comment:    c.linear,
+		URL:        c.url,
+		Author:     c.author,
+		WithAuthor: withAuthor,
+		CreatedAt:  c.createdAt,
+		EditedAt:   c.editedAt,
+		Reactions:  c.reactionsSummary,
+		Body:       c.text,
+	}
+	if parent := iss.comment(c.parentID); parent != nil {
+		d.ReplyTo = &replyTemplateData{
+			Author:  parent.author,
+			URL:     urls[parent.id],
+			Snippet: quoteSnippet(parent.text),
+		}
+		if d.ReplyTo.URL == "" {
+			d.ReplyTo.URL = parent.url
+		}
+	}
+	body, err := executeTemplate(commentTemplate, d)
+	if err != nil {
+		return "", fmt.Errorf("comment %s: %w", c.id, err)
+	}
+	return body, nil
+}
+
+func (iss *githubIssue) comment(id string) *githubComment {
+	if id == "" {
+		return nil
+	}
+	for _, c := range iss.comments {
+		if c.id == id {
+			return c
+		}
+	}
+	return nil
+}
+
+// quoteSnippet returns the first non empty line of s shortened to 100 characters.
+func quoteSnippet(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		r := []rune(line)
+		if len(r) > 100 {
+			return string(r[:100]) + "…"
+		}
+		return line
+	}
+	return ""
 }
 
 type githubProject struct {
 	name string
 	desc string
+	// readme and milestones are empty if projects.json is missing from the corpus.
+	readme     string
+	milestones []string
 }
 
-func fromLinearIssue(liss *linearIssue) *githubIssue {
-	body := fmt.Sprintf(`field | value
-| - | - |
-url | %s
-author | @%s
-date | %s
-state | %s
-project | %s
-priority | %s
-assignee | %s
-labels | %s
-related | %s
-parent | %s
-children | %s
-PRs | %s
-attachments | %s
-`,
-		liss.URL,
-		emailsToGithubMap[liss.Creator.Email],
-		formatTime(liss.CreatedAt),
-		liss.State.Name,
-		liss.Project.Name,
-		liss.PriorityLabel,
-		liss.assignee(),
-
-		formatArr(liss.labelsArr()),
-		formatArr(liss.relationsArr()),
-		liss.Parent.Identifier,
-		formatArr(liss.childrenArr()),
-		formatArr(liss.prs()),
-
-		formatArr(liss.attachmentsArr()),
-	)
-	if liss.Description != "" {
-		body += "\n" + liss.Description
+// withAuthor reports whether to-github creates liss with $GITHUB_TOKEN and so must
+// include its author in the body.
+func withAuthor(liss *linearIssue) bool {
+	return byelinearGithubImport != "" || githubUserClient(emailsToGithubMap[liss.Creator.Email]) == nil
+}
+
+// fromLinearIssue renders liss with the title and body templates. The author is left out
+// of the body unless withAuthor is set.
+func fromLinearIssue(liss *linearIssue, withAuthor bool) (*githubIssue, error) {
+	d := newIssueTemplateData(liss, withAuthor)
+	title, err := executeTemplate(titleTemplate, d)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", liss.Identifier, err)
+	}
+	body, err := executeTemplate(bodyTemplate, d)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", liss.Identifier, err)
 	}
 
 	iss := &githubIssue{
-		title: fmt.Sprintf("%s: %s", liss.Identifier, liss.Title),
-		body:  body,
-		state: liss.State.Name,
+		title:     strings.TrimSpace(title),
+		author:    d.Author,
+		body:      body,
+		state:     liss.State.Name,
+		createdAt: liss.CreatedAt,
+		closedAt:  liss.closedAt(),
+		reactions: fromLinearReactions(liss.Reactions),
+
+		dueDate:     liss.DueDate,
+		startedAt:   liss.StartedAt,
+		completedAt: liss.CompletedAt,
+	}
+	if byelinearClosedBy != "" && liss.stateType() == "completed" {
+		if prs := liss.mergedPRs(); len(prs) > 0 {
+			iss.closedBy = fmt.Sprintf("Closed by %s.", strings.Join(prs, ", "))
+		}
+	}
+	if byelinearHistory != "" {
+		iss.timeline = formatTimeline(liss)
 	}
 
 	for _, c := range liss.Comments.Nodes {
-		iss.comments = append(iss.comments, fmt.Sprintf(`field | value
-|-|-|
-url | %s
-author | @%s
-date | %s
-
-%s`,
-			c.URL,
-			emailsToGithubMap[c.User.Email],
-			formatTime(c.CreatedAt),
-			c.Body,
-		))
+		gcomment := &githubComment{
+			id:        c.ID,
+			parentID:  c.parentID(),
+			url:       c.URL,
+			createdAt: c.CreatedAt,
+			editedAt:  c.EditedAt,
+			text:      c.Body,
+			linear:    c,
+
+			reactionsSummary: formatReactions(c.Reactions),
+			reactions:        fromLinearReactions(c.Reactions),
+		}
+		// Comments by integrations and bots have no user.
+		if c.User != nil {
+			gcomment.author = emailsToGithubMap[c.User.Email]
+		}
+		iss.comments = append(iss.comments, gcomment)
 	}
 
 	if liss.Project.Name != "" {
@@ -178,9 +560,19 @@ date | %s
 			name: liss.Project.Name,
 			desc: liss.Project.Desc,
 		}
+		if lp, ok := linearProjects[liss.Project.Name]; ok {
+			iss.project.readme = lp.readme()
+			iss.project.milestones = lp.milestones()
+		}
+	}
+	if liss.ProjectMilestone != nil {
+		iss.milestone = liss.ProjectMilestone.Name
+	}
+	if len(liss.Needs.Nodes) > 0 {
+		iss.customerNeeds = formatCustomerNeedsTable(liss.Needs.Nodes)
 	}
 	if liss.Assignee != nil {
-		iss.assignee = emailsToGithubMap[liss.Assignee.Email]
+		iss.assignee = d.Assignee
 	}
 	for _, linearLabel := range liss.Labels.Nodes {
 		iss.labels = append(iss.labels, &githubLabel{
@@ -189,7 +581,18 @@ date | %s
 			desc:  linearLabel.Description,
 		})
 	}
-	return iss
+	if liss.stateType() == "triage" {
+		iss.labels = append(iss.labels, triageLabel)
+	}
+	// Comments are rendered as they are created but an error in the comment template
+	// must fail before the issue is created rather than on every retry.
+	for _, c := range iss.comments {
+		_, err = iss.commentBody(c, true, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", liss.Identifier, err)
+		}
+	}
+	return iss, nil
 }
 
 var emailsToGithubMap = map[string]string{
@@ -332,16 +735,29 @@ func queryStatusField(ctx context.Context, hc *http.Client, pnum int) (*statusFi
 	return si, nil
 }
 
+// githubStatus returns the Status option of issues in linearState or "" if their Status
+// is left empty.
+func githubStatus(linearState string) string {
+	switch linearState {
+	case "Todo":
+		return "Todo"
+	case "In Progress", "In Review":
+		return "In Progress"
+	case "Done", "Canceled":
+		return "Done"
+	default:
+		return ""
+	}
+}
+
 func setProjectIssueStatus(ctx context.Context, hc *http.Client, projectID, issID string, si *statusFieldInfo, linearState string) error {
 	var optionID string
-	switch linearState {
-	case "Backlog":
-		return nil
+	switch githubStatus(linearState) {
 	case "Todo":
 		optionID = si.TodoID
-	case "In Progress", "In Review":
+	case "In Progress":
 		optionID = si.InProgressID
-	case "Done", "Canceled":
+	case "Done":
 		optionID = si.DoneID
 	default:
 		return nil
@@ -384,6 +800,14 @@ func ensureProject(ctx context.Context, hc *http.Client, name, desc string) (str
 		if err != nil {
 			return "", 0, err
 		}
+		err = recordOperation(&journalEntry{
+			Kind:   journalProject,
+			Name:   name,
+			NodeID: pID,
+		})
+		if err != nil {
+			return "", 0, err
+		}
 	}
 
 	queryString := `mutation($projectId: ID!, $shortDescription: String) {
@@ -439,10 +863,17 @@ func ensureLabel(ctx context.Context, gc *github.Client, name, color, desc strin
 		Color:       &color,
 		Description: &desc,
 	})
-	if err != nil && !isAlreadyExistsErr(err) {
+	if isAlreadyExistsErr(err) {
+		return nil
+	}
+	if err != nil {
 		return err
 	}
-	return nil
+	return recordOperation(&journalEntry{
+		Kind: journalLabel,
+		Repo: orgName + "/" + repoName,
+		Name: name,
+	})
 }
 
 func isAlreadyExistsErr(err error) bool {
@@ -450,8 +881,25 @@ func isAlreadyExistsErr(err error) bool {
 	return errors.As(err, &ghErr) && len(ghErr.Errors) == 1 && ghErr.Errors[0].Code == "already_exists"
 }
 
+// newGithubClient returns a client for $BYELINEAR_GITHUB_URL whose requests, including
+// GraphQL queries made with its Client, share githubLimiter.
+func newGithubClient(hc *http.Client) (*github.Client, error) {
+	if githubLimiter != nil {
+		limited := *hc
+		limited.Transport = &rateLimitedTransport{limiter: githubLimiter, base: hc.Transport}
+		hc = &limited
+	}
+	gc := github.NewClient(hc)
+	baseURL, err := url.Parse(byelinearGithubURL)
+	if err != nil {
+		return nil, fmt.Errorf("$BYELINEAR_GITHUB_URL: %w", err)
+	}
+	gc.BaseURL = baseURL
+	return gc, nil
+}
+
 func doGithubQuery(ctx context.Context, hc *http.Client, qreq *graphqlQuery, resp interface{}) error {
-	b, _, err := doGraphQLQuery(ctx, "https://api.github.com/graphql", hc, qreq)
+	b, _, err := doGraphQLQuery(ctx, byelinearGithubURL+"graphql", hc, qreq)
 	if err != nil {
 		return err
 	}
@@ -511,28 +959,13 @@ func addIssueToProject(ctx context.Context, hc *http.Client, pID, iID string) (s
 	return queryResp.Data.AddProjectV2ItemById.Item.ID, nil
 }
 
-func formatArr(v interface{}) string {
-	s := fmt.Sprintf("%v", v)
-	if s[0] == '[' && s[len(s)-1] == ']' {
-		return s[1 : len(s)-1]
-	}
-	return s
-}
-
 func (s *state) hasLabel(name string) bool {
-	for _, l := range s.Labels {
-		if l == name {
-			return true
-		}
-	}
-	return false
+	s.index()
+	return s.labelIndex[name]
 }
 
 func (s *state) hasProject(name string) (*projectState, bool) {
-	for _, p := range s.Projects {
-		if p.Name == name {
-			return p, true
-		}
-	}
-	return nil, false
+	s.index()
+	p, ok := s.projectIndex[name]
+	return p, ok
 }
@@ -4,6 +4,7 @@ go 1.18
 
 require (
 	github.com/google/go-github/v47 v47.0.0
+	go.etcd.io/bbolt v1.3.7
 	golang.org/x/oauth2 v0.0.0-20220909003341-f21342109be1
 )
 
@@ -12,6 +13,7 @@ require (
 	github.com/google/go-querystring v1.1.0 // indirect
 	golang.org/x/crypto v0.0.0-20210817164053-32db794688a5 // indirect
 	golang.org/x/net v0.0.0-20220624214902-1bab6f366d9e // indirect
+	golang.org/x/sys v0.4.0 // indirect
 	google.golang.org/appengine v1.6.7 // indirect
 	google.golang.org/protobuf v1.28.0 // indirect
 )
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+type linearHistory struct {
+	CreatedAt    time.Time   `json:"createdAt"`
+	Actor        *linearUser `json:"actor"`
+	FromState    *linearName `json:"fromState"`
+	ToState      *linearName `json:"toState"`
+	FromAssignee *linearUser `json:"fromAssignee"`
+	ToAssignee   *linearUser `json:"toAssignee"`
+	FromPriority *float64    `json:"fromPriority"`
+	ToPriority   *float64    `json:"toPriority"`
+	FromProject  *linearName `json:"fromProject"`
+	ToProject    *linearName `json:"toProject"`
+	FromTitle    string      `json:"fromTitle"`
+	ToTitle      string      `json:"toTitle"`
+	AddedLabels  []struct {
+		Name string `json:"name"`
+	} `json:"addedLabels"`
+	RemovedLabels []struct {
+		Name string `json:"name"`
+	} `json:"removedLabels"`
+}
+
+type linearName struct {
+	Name string `json:"name"`
+}
+
+func queryLinearHistory(ctx context.Context, hc *http.Client, issueID, after string) ([]*linearHistory, linearPageInfo, error) {
+	queryString := `query($id: String!, $after: String) {
+		issue(id: $id) {
+			history(first: 100, after: $after) {
+				pageInfo {
+					hasNextPage
+					endCursor
+				}
+				nodes {
+					createdAt
+					actor {
+						name
+						email
+					}
+					fromState {
+						name
+					}
+					toState {
+						name
+					}
+					fromAssignee {
+						name
+						email
+					}
+					toAssignee {
+						name
+						email
+					}
+					fromPriority
+					toPriority
+					fromProject {
+						name
+					}
+					toProject {
+						name
+					}
+					fromTitle
+					toTitle
+					addedLabels {
+						name
+					}
+					removedLabels {
+						name
+					}
+				}
+			}
+		}
+	}`
+	var queryResp struct {
+		Data struct {
+			Issue struct {
+				History struct {
+					PageInfo linearPageInfo   `json:"pageInfo"`
+					Nodes    []*linearHistory `json:"nodes"`
+				} `json:"history"`
+			} `json:"issue"`
+		} `json:"data"`
+	}
+
+	qreq := &graphqlQuery{
+		Query:     queryString,
+		Variables: map[string]interface{}{"id": issueID, "after": after},
+	}
+	err := doLinearQuery(ctx, hc, qreq, &queryResp)
+	if err != nil {
+		return nil, linearPageInfo{}, err
+	}
+	return queryResp.Data.Issue.History.Nodes, queryResp.Data.Issue.History.PageInfo, nil
+}
+
+func (li *linearIssue) fetchHistory(ctx context.Context, hc *http.Client) error {
+	li.History = nil
+	pageInfo := linearPageInfo{HasNextPage: true}
+	for pageInfo.HasNextPage {
+		var history []*linearHistory
+		var err error
+		history, pageInfo, err = queryLinearHistory(ctx, hc, li.ID, pageInfo.EndCursor)
+		if err != nil {
+			return err
+		}
+		li.History = append(li.History, history...)
+	}
+	sort.SliceStable(li.History, func(i, j int) bool {
+		return li.History[i].CreatedAt.Before(li.History[j].CreatedAt)
+	})
+	return nil
+}
+
+// changes describes each change in the history entry.
+func (lh *linearHistory) changes() []string {
+	var a []string
+	if lh.FromState != nil || lh.ToState != nil {
+		a = append(a, fmt.Sprintf("state: %s → %s", lh.FromState.name(), lh.ToState.name()))
+	}
+	if lh.FromAssignee != nil || lh.ToAssignee != nil {
+		a = append(a, fmt.Sprintf("assignee: %s → %s", formatLinearUser(lh.FromAssignee), formatLinearUser(lh.ToAssignee)))
+	}
+	if lh.FromPriority != nil && lh.ToPriority != nil && *lh.FromPriority != *lh.ToPriority {
+		a = append(a, fmt.Sprintf("priority: %s → %s", priorityLabel(*lh.FromPriority), priorityLabel(*lh.ToPriority)))
+	}
+	if lh.FromProject != nil || lh.ToProject != nil {
+		a = append(a, fmt.Sprintf("project: %s → %s", lh.FromProject.name(), lh.ToProject.name()))
+	}
+	if lh.FromTitle != "" && lh.ToTitle != "" {
+		a = append(a, fmt.Sprintf("title: %s → %s", lh.FromTitle, lh.ToTitle))
+	}
+	for _, l := range lh.AddedLabels {
+		a = append(a, "added label: "+l.Name)
+	}
+	for _, l := range lh.RemovedLabels {
+		a = append(a, "removed label: "+l.Name)
+	}
+	return a
+}
+
+func (ln *linearName) name() string {
+	if ln == nil {
+		return "none"
+	}
+	return ln.Name
+}
+
+func formatLinearUser(lu *linearUser) string {
+	if lu == nil {
+		return "none"
+	}
+	if login := emailsToGithubMap[lu.Email]; login != "" {
+		return "@" + login
+	}
+	return lu.Name
+}
+
+func priorityLabel(p float64) string {
+	switch p {
+	case 1:
+		return "Urgent"
+	case 2:
+		return "High"
+	case 3:
+		return "Medium"
+	case 4:
+		return "Low"
+	}
+	return "No priority"
+}
+
+// formatTimeline returns a collapsed table of the issue's history or "" if there is
+// none.
+func formatTimeline(liss *linearIssue) string {
+	var rows []string
+	for _, lh := range liss.History {
+		for _, c := range lh.changes() {
+			rows = append(rows, fmt.Sprintf("%s | %s | %s", formatTime(lh.CreatedAt), formatLinearUser(lh.Actor), c))
+		}
+	}
+	if len(rows) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(`<details>
+<summary>Linear history</summary>
+
+date | actor | change
+| - | - | - |
+%s
+</details>`, strings.Join(rows, "\n"))
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+)
+
+// importToGithub creates the issue and its comments with GitHub's issue import API.
+// Unlike createGithubIssue the import is atomic and preserves the original timestamps.
+//
+// See https://gist.github.com/jonmagic/5282384165e0f86ef105
+func (s *state) importToGithub(ctx context.Context, gc *github.Client, e *githubExport) error {
+	ident, iss := e.ident(), e.iss
+	err := s.ensureLabels(ctx, gc, ident, iss)
+	if err != nil {
+		return err
+	}
+
+	// The import ID is saved before polling so that a retry resumes polling the import
+	// instead of importing the issue again.
+	var imp *githubImport
+	if e.state.GithubImportID != 0 {
+		log.Printf("%s: resuming import %d", ident, e.state.GithubImportID)
+		imp = &githubImport{ID: e.state.GithubImportID, Status: "pending"}
+	} else {
+		log.Printf("%s: importing", ident)
+		imp, err = startIssueImport(ctx, gc, iss)
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		e.state.GithubImportID = imp.ID
+		s.mu.Unlock()
+		err = writeState(s)
+		if err != nil {
+			return err
+		}
+	}
+	for imp.Status == "pending" {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+		imp, err = queryIssueImport(ctx, gc, imp.ID)
+		if err != nil {
+			return err
+		}
+	}
+	if imp.Status != "imported" {
+		// Nothing was created so the retry imports the issue again.
+		s.mu.Lock()
+		e.state.GithubImportID = 0
+		s.mu.Unlock()
+		err = writeState(s)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("import %d %s: %v", imp.ID, imp.Status, imp.Errors)
+	}
+
+	num, err := strconv.Atoi(path.Base(imp.IssueURL))
+	if err != nil {
+		return fmt.Errorf("import %d: unexpected issue_url %q", imp.ID, imp.IssueURL)
+	}
+	// The imported comments are deleted with the issue by rollback so their IDs are not
+	// needed.
+	err = recordOperation(&journalEntry{
+		Kind:   journalIssue,
+		Ident:  ident,
+		Repo:   orgName + "/" + repoName,
+		Number: num,
+	})
+	if err != nil {
+		return err
+	}
+	giss, _, err := gc.Issues.Get(ctx, orgName, repoName, num)
+	if err != nil {
+		return err
+	}
+	e.number = num
+	e.nodeID = giss.GetNodeID()
+	e.url = giss.GetHTMLURL()
+	return nil
+}
+
+type githubImportRequest struct {
+	Issue    *githubImportIssue     `json:"issue"`
+	Comments []*githubImportComment `json:"comments,omitempty"`
+}
+
+type githubImportIssue struct {
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"created_at"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty"`
+	Assignee  string     `json:"assignee,omitempty"`
+	Closed    bool       `json:"closed"`
+	Labels    []string   `json:"labels,omitempty"`
+}
+
+type githubImportComment struct {
+	CreatedAt time.Time `json:"created_at"`
+	Body      string    `json:"body"`
+}
+
+type githubImport struct {
+	ID       int    `json:"id"`
+	Status   string `json:"status"`
+	IssueURL string `json:"issue_url"`
+	Errors   []struct {
+		Location string `json:"location"`
+		Resource string `json:"resource"`
+		Field    string `json:"field"`
+		Value    string `json:"value"`
+		Code     string `json:"code"`
+	} `json:"errors"`
+}
+
+func startIssueImport(ctx context.Context, gc *github.Client, iss *githubIssue) (*githubImport, error) {
+	ireq := &githubImportRequest{
+		Issue: &githubImportIssue{
+			Title:     iss.title,
+			Body:      iss.body,
+			CreatedAt: iss.createdAt,
+			ClosedAt:  iss.closedAt,
+			Assignee:  iss.assignee,
+			Closed:    iss.closed(),
+		},
+	}
+	for _, l := range iss.labels {
+		ireq.Issue.Labels = append(ireq.Issue.Labels, l.name)
+	}
+	for _, c := range iss.comments {
+		body, err := iss.commentBody(c, true, nil)
+		if err != nil {
+			return nil, err
+		}
+		ireq.Comments = append(ireq.Comments, &githubImportComment{
+			CreatedAt: c.createdAt,
+			Body:      body,
+		})
+	}
+	if iss.closedBy != "" && iss.closedAt != nil {
+		ireq.Comments = append(ireq.Comments, &githubImportComment{
+			CreatedAt: *iss.closedAt,
+			Body:      iss.closedBy,
+		})
+	}
+	if iss.timeline != "" {
+		ireq.Comments = append(ireq.Comments, &githubImportComment{
+			CreatedAt: time.Now(),
+			Body:      iss.timeline,
+		})
+	}
+
+	req, err := gc.NewRequest("POST", fmt.Sprintf("repos/%s/%s/import/issues", orgName, repoName), ireq)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.golden-comet-preview+json")
+
+	var imp *githubImport
+	_, err = gc.Do(ctx, req, &imp)
+	if err != nil {
+		return nil, err
+	}
+	return imp, nil
+}
+
+func queryIssueImport(ctx context.Context, gc *github.Client, id int) (*githubImport, error) {
+	req, err := gc.NewRequest("GET", fmt.Sprintf("repos/%s/%s/import/issues/%d", orgName, repoName, id), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.golden-comet-preview+json")
+
+	var imp *githubImport
+	_, err = gc.Do(ctx, req, &imp)
+	if err != nil {
+		return nil, err
+	}
+	return imp, nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newImportStub serves the issue import API of org/repo. The import is pending for the
+// first poll and then reported with status and issueURL. The returned request is nil
+// until an import is started.
+func newImportStub(t *testing.T, status, issueURL string) (*httptest.Server, **githubImportRequest) {
+	var ireq *githubImportRequest
+	polls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/import/issues", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("unexpected method %s", r.Method)
+		}
+		ireq = &githubImportRequest{}
+		err := json.NewDecoder(r.Body).Decode(ireq)
+		if err != nil {
+			t.Error(err)
+		}
+		fmt.Fprint(w, `{"id": 7, "status": "pending"}`)
+	})
+	mux.HandleFunc("/repos/org/repo/import/issues/7", func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		if polls == 1 {
+			fmt.Fprint(w, `{"id": 7, "status": "pending"}`)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":        7,
+			"status":    status,
+			"issue_url": issueURL,
+			"errors": []map[string]string{
+				{"resource": "Issue", "field": "title", "code": "missing_field"},
+			},
+		})
+	})
+	mux.HandleFunc("/repos/org/repo/issues/42", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"number": 42, "node_id": "I_42", "html_url": "https://github.com/org/repo/issues/42"}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, &ireq
+}
+
+// setTestGlobals points the corpus and GitHub globals at c, dir and githubURL with
+// org/repo until the test ends.
+func setTestGlobals(t *testing.T, c corpusBackend, dir, githubURL string) {
+	oldCorpus, oldDir, oldURL := corpus, byelinearCorpus, byelinearGithubURL
+	oldOrg, oldRepo := orgName, repoName
+	t.Cleanup(func() {
+		corpus, byelinearCorpus, byelinearGithubURL = oldCorpus, oldDir, oldURL
+		orgName, repoName = oldOrg, oldRepo
+	})
+	corpus, byelinearCorpus, byelinearGithubURL = c, dir, githubURL
+	orgName, repoName = "org", "repo"
+}
+
+func TestImportToGithub(t *testing.T) {
+	testCases := []struct {
+		name     string
+		status   string
+		issueURL string
+		// importID is the import saved by an earlier attempt.
+		importID int
+		err      string
+	}{
+		{
+			name:     "imported",
+			status:   "imported",
+			issueURL: "https://api.github.com/repos/org/repo/issues/42",
+		},
+		{
+			name:     "resumed",
+			status:   "imported",
+			issueURL: "https://api.github.com/repos/org/repo/issues/42",
+			importID: 7,
+		},
+		{
+			name:   "failed",
+			status: "failed",
+			err:    "import 7 failed",
+		},
+		{
+			name:     "bad_issue_url",
+			status:   "imported",
+			issueURL: "https://api.github.com/repos/org/repo/issues/",
+			err:      "unexpected issue_url",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			srv, ireq := newImportStub(t, tc.status, tc.issueURL)
+			setTestGlobals(t, jsonCorpus{}, t.TempDir(), srv.URL+"/")
+
+			gc, err := newGithubClient(srv.Client())
+			if err != nil {
+				t.Fatal(err)
+			}
+			createdAt := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+			iss := &issueState{Identifier: "TER-1", GithubImportID: tc.importID}
+			e := &githubExport{
+				state: iss,
+				iss: &githubIssue{
+					title:     "TER-1: title",
+					body:      "body",
+					state:     "Todo",
+					createdAt: createdAt,
+					comments: []*githubComment{
+						{id: "c1", url: "https://linear.app/c1", author: "alixander", createdAt: createdAt, text: "comment"},
+					},
+				},
+			}
+			err = (&state{Issues: []*issueState{iss}}).importToGithub(context.Background(), gc, e)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error containing %q: %v", tc.err, err)
+				}
+				if tc.status == "failed" && iss.GithubImportID != 0 {
+					t.Errorf("failed import %d is still saved", iss.GithubImportID)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if iss.GithubImportID != 7 {
+				t.Errorf("unexpected import ID %d", iss.GithubImportID)
+			}
+
+			switch {
+			case tc.importID != 0:
+				if *ireq != nil {
+					t.Errorf("resumed import was started again: %+v", (*ireq).Issue)
+				}
+			case *ireq == nil:
+				t.Error("no import was started")
+			default:
+				ireq := *ireq
+				if ireq.Issue.Title != "TER-1: title" || ireq.Issue.Closed || !ireq.Issue.CreatedAt.Equal(createdAt) {
+					t.Errorf("unexpected import issue: %+v", ireq.Issue)
+				}
+				if len(ireq.Comments) != 1 || !strings.HasSuffix(ireq.Comments[0].Body, "\n\ncomment") {
+					t.Errorf("unexpected import comments: %+v", ireq.Comments)
+				}
+			}
+			if e.number != 42 || e.nodeID != "I_42" || e.url != "https://github.com/org/repo/issues/42" {
+				t.Errorf("unexpected export: number %d, node ID %q, url %q", e.number, e.nodeID, e.url)
+			}
+			entries, err := readJournal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 || entries[0].Kind != journalIssue || entries[0].Number != 42 {
+				t.Errorf("unexpected journal: %+v", entries)
+			}
+		})
+	}
+}
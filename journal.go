@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Kinds of journal entries.
+const (
+	journalIssue   = "issue"
+	journalComment = "comment"
+	journalLabel   = "label"
+	journalProject = "project"
+)
+
+// journalEntry records a GitHub object created by to-github so that rollback can remove
+// it. Labels and projects that already existed are not recorded.
+type journalEntry struct {
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"`
+	// Ident is the identifier of the Linear issue being exported, if any.
+	Ident string `json:"ident,omitempty"`
+	// Repo is the org/repo of issues, comments and labels.
+	Repo string `json:"repo,omitempty"`
+	// Number is the number of the issue or of the issue of the comment.
+	Number int   `json:"number,omitempty"`
+	ID     int64 `json:"id,omitempty"`
+	// Name is the name of the label or the title of the project.
+	Name string `json:"name,omitempty"`
+	// NodeID is the GraphQL ID of the project.
+	NodeID string `json:"node_id,omitempty"`
+}
+
+// journalMu serializes appends to the journal.
+var journalMu sync.Mutex
+
+func journalPath() string {
+	return filepath.Join(byelinearCorpus, "journal.jsonl")
+}
+
+// recordOperation appends e to the journal and syncs it before returning.
+func recordOperation(e *journalEntry) error {
+	journalMu.Lock()
+	defer journalMu.Unlock()
+
+	e.Time = time.Now()
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(journalPath(), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(b, '\n'))
+	if err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// readJournal returns the entries of the journal in the order they were recorded. A
+// final line truncated by a crash is ignored.
+func readJournal() ([]*journalEntry, error) {
+	f, err := os.Open(journalPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []*journalEntry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e *journalEntry
+		err = json.Unmarshal(sc.Bytes(), &e)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
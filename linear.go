@@ -6,11 +6,20 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"strconv"
+	"sort"
 	"time"
 )
 
+// doLinearQuery waits for $BYELINEAR_LINEAR_RATE and then gives the query two minutes.
+// The timeout is per query rather than per page of issues as a page may take any number
+// of queries to hydrate.
 func doLinearQuery(ctx context.Context, hc *http.Client, qreq *graphqlQuery, resp interface{}) error {
+	err := linearLimiter.wait(ctx)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Minute*2)
+	defer cancel()
 	b, httpResp, err := doGraphQLQuery(ctx, "https://api.linear.app/graphql", hc, qreq)
 	if os.Getenv("DEBUG") != "" {
 		if httpResp != nil && httpResp.Header.Get("X-Complexity") != "" {
@@ -24,14 +33,18 @@ func doLinearQuery(ctx context.Context, hc *http.Client, qreq *graphqlQuery, res
 }
 
 func queryLinearIssues(ctx context.Context, hc *http.Client, before string) ([]*linearIssue, error) {
-	queryString := `query($before: String, $number: Float) {
-		issues(last: 50, before: $before, filter: {number: {eq: $number}}, includeArchived: true) {
+	queryString := `query($before: String, $filter: IssueFilter, $includeArchived: Boolean) {
+		issues(last: 50, before: $before, filter: $filter, includeArchived: $includeArchived) {
 			nodes {
 				id
 				url
 				identifier
 				title
 				description
+				team {
+					key
+					name
+				}
 				creator {
 					name
 					email
@@ -43,12 +56,31 @@ func queryLinearIssues(ctx context.Context, hc *http.Client, before string) ([]*
 				priorityLabel
 				state {
 					name
+					type
 				}
 				project {
 					name
 					description
 				}
+				projectMilestone {
+					name
+				}
+				reactions {
+					emoji
+					user {
+						name
+						email
+					}
+				}
 				createdAt
+				updatedAt
+				archivedAt
+				completedAt
+				canceledAt
+				startedAt
+				dueDate
+				slaStartedAt
+				slaBreachesAt
 				labels(last: 10) {
 					nodes {
 						name
@@ -56,20 +88,22 @@ func queryLinearIssues(ctx context.Context, hc *http.Client, before string) ([]*
 						description
 					}
 				}
-				comments(last: 10) {
+				comments(first: 25) {
+					pageInfo {
+						hasNextPage
+						endCursor
+					}
 					nodes {
-						url
-						user {
-							name
-							email
-						}
-						createdAt
-						body
+						` + linearCommentFields + `
 					}
 				}
-				attachments(last: 10) {
+				attachments(first: 50) {
 					nodes {
 						url
+						title
+						subtitle
+						sourceType
+						metadata
 					}
 				}
 				relations(last: 10) {
@@ -82,6 +116,20 @@ func queryLinearIssues(ctx context.Context, hc *http.Client, before string) ([]*
 				parent {
 					identifier
 				}
+				needs(first: 50) {
+					nodes {
+						body
+						priority
+						createdAt
+						customer {
+							name
+						}
+						attachment {
+							title
+							url
+						}
+					}
+				}
 				children(last: 10) {
 					nodes {
 						identifier
@@ -98,30 +146,135 @@ func queryLinearIssues(ctx context.Context, hc *http.Client, before string) ([]*
 		} `json:"data"`
 	}
 
+	lf, includeArchived := filter.linear()
 	qreq := &graphqlQuery{
-		Query:     queryString,
-		Variables: map[string]interface{}{},
+		Query: queryString,
+		Variables: map[string]interface{}{
+			"filter":          lf,
+			"includeArchived": includeArchived,
+		},
 	}
 	if before != "" {
 		qreq.Variables["before"] = before
 	}
-	number, err := strconv.Atoi(byelinearIssueNumber)
-	if err == nil {
-		qreq.Variables["number"] = number
-	}
-	err = doLinearQuery(ctx, hc, qreq, &queryResp)
+	err := doLinearQuery(ctx, hc, qreq, &queryResp)
 	if err != nil {
 		return nil, err
 	}
 	return queryResp.Data.Issues.Nodes, nil
 }
 
+const linearCommentFields = `id
+						url
+						user {
+							name
+							email
+						}
+						parent {
+							id
+						}
+						createdAt
+						updatedAt
+						editedAt
+						body
+						reactions {
+							emoji
+							user {
+								name
+								email
+							}
+						}`
+
+// queryLinearComments returns the comments of the issue after the cursor.
+func queryLinearComments(ctx context.Context, hc *http.Client, issueID, after string) ([]*linearComment, linearPageInfo, error) {
+	queryString := `query($id: String!, $after: String) {
+		issue(id: $id) {
+			comments(first: 100, after: $after) {
+				pageInfo {
+					hasNextPage
+					endCursor
+				}
+				nodes {
+					` + linearCommentFields + `
+				}
+			}
+		}
+	}`
+	var queryResp struct {
+		Data struct {
+			Issue struct {
+				Comments struct {
+					PageInfo linearPageInfo   `json:"pageInfo"`
+					Nodes    []*linearComment `json:"nodes"`
+				} `json:"comments"`
+			} `json:"issue"`
+		} `json:"data"`
+	}
+
+	qreq := &graphqlQuery{
+		Query:     queryString,
+		Variables: map[string]interface{}{"id": issueID, "after": after},
+	}
+	err := doLinearQuery(ctx, hc, qreq, &queryResp)
+	if err != nil {
+		return nil, linearPageInfo{}, err
+	}
+	return queryResp.Data.Issue.Comments.Nodes, queryResp.Data.Issue.Comments.PageInfo, nil
+}
+
+// fetchRemainingComments fetches the comments that did not fit in the first page of
+// the issues query and sorts all comments by creation time.
+func (li *linearIssue) fetchRemainingComments(ctx context.Context, hc *http.Client) error {
+	for li.Comments.PageInfo.HasNextPage {
+		comments, pageInfo, err := queryLinearComments(ctx, hc, li.ID, li.Comments.PageInfo.EndCursor)
+		if err != nil {
+			return err
+		}
+		li.Comments.Nodes = append(li.Comments.Nodes, comments...)
+		li.Comments.PageInfo = pageInfo
+	}
+	sort.SliceStable(li.Comments.Nodes, func(i, j int) bool {
+		return li.Comments.Nodes[i].CreatedAt.Before(li.Comments.Nodes[j].CreatedAt)
+	})
+	return nil
+}
+
+type linearPageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+type linearComment struct {
+	ID     string      `json:"id"`
+	URL    string      `json:"url"`
+	User   *linearUser `json:"user"`
+	Parent *struct {
+		ID string `json:"id"`
+	} `json:"parent"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// EditedAt is nil unless the body was edited.
+	EditedAt  *time.Time        `json:"editedAt"`
+	Body      string            `json:"body"`
+	Reactions []*linearReaction `json:"reactions"`
+}
+
+func (lc *linearComment) parentID() string {
+	if lc.Parent == nil {
+		return ""
+	}
+	return lc.Parent.ID
+}
+
 type linearUser struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
 }
 
 type linearIssue struct {
+	// SchemaVersion is set when writing the issue into the corpus. See corpusSchemaVersion.
+	SchemaVersion int `json:"schema_version"`
+
 	ID            string      `json:"id"`
 	URL           string      `json:"url"`
 	Identifier    string      `json:"identifier"`
@@ -132,13 +285,33 @@ type linearIssue struct {
 	PriorityLabel string      `json:"priorityLabel"`
 	State         struct {
 		Name string `json:"name"`
+		Type string `json:"type"`
 	} `json:"state"`
 	Project struct {
 		Name string `json:"name"`
 		Desc string `json:"description"`
 	} `json:"project"`
-	CreatedAt time.Time `json:"createdAt"`
-	Labels    struct {
+	ProjectMilestone *struct {
+		Name string `json:"name"`
+	} `json:"projectMilestone"`
+	Team struct {
+		Key  string `json:"key"`
+		Name string `json:"name"`
+	} `json:"team"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+	ArchivedAt *time.Time `json:"archivedAt"`
+	// CompletedAt and CanceledAt are nil unless the issue is completed or canceled.
+	CompletedAt *time.Time `json:"completedAt"`
+	CanceledAt  *time.Time `json:"canceledAt"`
+	StartedAt   *time.Time `json:"startedAt"`
+	// DueDate is formatted as YYYY-MM-DD.
+	DueDate       string            `json:"dueDate"`
+	SLAStartedAt  *time.Time        `json:"slaStartedAt"`
+	SLABreachesAt *time.Time        `json:"slaBreachesAt"`
+	Reactions     []*linearReaction `json:"reactions"`
+	History       []*linearHistory  `json:"history"`
+	Labels        struct {
 		Nodes []struct {
 			Name        string `json:"name"`
 			Color       string `json:"color"`
@@ -146,12 +319,8 @@ type linearIssue struct {
 		} `json:"nodes"`
 	} `json:"labels"`
 	Comments struct {
-		Nodes []struct {
-			URL       string      `json:"url"`
-			User      *linearUser `json:"user"`
-			CreatedAt time.Time   `json:"createdAt"`
-			Body      string      `json:"body"`
-		} `json:"nodes"`
+		PageInfo linearPageInfo   `json:"pageInfo"`
+		Nodes    []*linearComment `json:"nodes"`
 	} `json:"comments"`
 	Relations struct {
 		Nodes []struct {
@@ -169,10 +338,11 @@ type linearIssue struct {
 		} `json:"nodes"`
 	} `json:"children"`
 	Attachments struct {
-		Nodes []struct {
-			URL string `json:"url"`
-		} `json:"nodes"`
+		Nodes []*linearAttachment `json:"nodes"`
 	} `json:"attachments"`
+	Needs struct {
+		Nodes []*linearCustomerNeed `json:"nodes"`
+	} `json:"needs"`
 }
 
 func (li *linearIssue) labelsArr() []string {
@@ -199,31 +369,45 @@ func (li *linearIssue) childrenArr() []string {
 	return a
 }
 
-func (li *linearIssue) assignee() string {
-	if li.Assignee == nil {
-		return ""
+// prs returns references to the GitHub pull requests and commits attached to the issue
+// so that GitHub cross-links them.
+func (li *linearIssue) prs() []string {
+	var prs []string
+	for _, att := range li.Attachments.Nodes {
+		if ref, ok := att.githubRef(); ok {
+			prs = append(prs, ref.String())
+		}
 	}
-	return "@" + emailsToGithubMap[li.Assignee.Email]
+	return prs
 }
 
-func (li *linearIssue) prs() []string {
+// mergedPRs returns references to the merged GitHub pull requests attached to the issue.
+func (li *linearIssue) mergedPRs() []string {
 	var prs []string
-	// for _, ir := range li.IntegrationResources.Nodes {
-	// 	if ir.PullRequest != nil {
-	// 		if ir.PullRequest.RepoLogin == orgName && ir.PullRequest.RepoName == repoName {
-	// 			prs = append(prs, fmt.Sprintf("#%d", ir.PullRequest.Number))
-	// 		} else {
-	// 			prs = append(prs, fmt.Sprintf("%s/%s#%d", ir.PullRequest.RepoLogin, ir.PullRequest.RepoName, ir.PullRequest.Number))
-	// 		}
-	// 	}
-	// }
+	for _, att := range li.Attachments.Nodes {
+		if ref, ok := att.githubRef(); ok && ref.merged {
+			prs = append(prs, ref.String())
+		}
+	}
 	return prs
 }
 
-func (li *linearIssue) attachmentsArr() []string {
-	var a []string
-	for _, att := range li.Attachments.Nodes {
-		a = append(a, att.URL)
+// closedAt returns when the issue was completed or canceled. Corpora fetched before
+// completedAt and canceledAt were fetched fall back to when the issue was last updated.
+func (li *linearIssue) closedAt() *time.Time {
+	switch {
+	case li.CompletedAt != nil:
+		return li.CompletedAt
+	case li.CanceledAt != nil:
+		return li.CanceledAt
 	}
-	return a
+	switch li.stateType() {
+	case "completed", "canceled":
+		t := li.UpdatedAt
+		if t.IsZero() {
+			t = li.CreatedAt
+		}
+		return &t
+	}
+	return nil
 }
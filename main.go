@@ -1,15 +1,19 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-github/v47/github"
@@ -17,38 +21,167 @@ import (
 )
 
 var byelinearIssueNumber = os.Getenv("BYELINEAR_ISSUE_NUMBER")
+var byelinearFilter = os.Getenv("BYELINEAR_FILTER")
 var byelinearCorpus = os.Getenv("BYELINEAR_CORPUS")
+var byelinearCorpusBackend = os.Getenv("BYELINEAR_CORPUS_BACKEND")
+var byelinearPolicy = os.Getenv("BYELINEAR_POLICY")
+var byelinearArchive = os.Getenv("BYELINEAR_ARCHIVE")
 
 var orgName = os.Getenv("BYELINEAR_ORG")
 var repoName = os.Getenv("BYELINEAR_REPO")
+var byelinearGithubImport = os.Getenv("BYELINEAR_GITHUB_IMPORT")
+var byelinearGithubURL = os.Getenv("BYELINEAR_GITHUB_URL")
+var byelinearUserTokens = os.Getenv("BYELINEAR_USER_TOKENS")
+var byelinearHistory = os.Getenv("BYELINEAR_HISTORY")
+var byelinearClosedBy = os.Getenv("BYELINEAR_CLOSED_BY")
+var byelinearDocsDir = os.Getenv("BYELINEAR_DOCS_DIR")
+var byelinearCustomers = os.Getenv("BYELINEAR_CUSTOMERS")
+var byelinearCustomersRepo = os.Getenv("BYELINEAR_CUSTOMERS_REPO")
+var byelinearRedact = os.Getenv("BYELINEAR_REDACT")
+var byelinearLinearWorkers = os.Getenv("BYELINEAR_LINEAR_WORKERS")
+var byelinearLinearRate = os.Getenv("BYELINEAR_LINEAR_RATE")
+var byelinearGithubWorkers = os.Getenv("BYELINEAR_GITHUB_WORKERS")
+var byelinearGithubRate = os.Getenv("BYELINEAR_GITHUB_RATE")
+var byelinearPreserveNumbers = os.Getenv("BYELINEAR_PRESERVE_NUMBERS")
+var byelinearTemplates = os.Getenv("BYELINEAR_TEMPLATES")
 
 var githubToken = os.Getenv("GITHUB_TOKEN")
 var linearAPIKey = os.Getenv("LINEAR_API_KEY")
 
+// filter is parsed from $BYELINEAR_FILTER and $BYELINEAR_ISSUE_NUMBER.
+var filter *issueFilter
+
+// policy is parsed from $BYELINEAR_POLICY.
+var policy *exportPolicy
+
+// linearWorkers is parsed from $BYELINEAR_LINEAR_WORKERS.
+var linearWorkers int
+
+// linearLimiter is parsed from $BYELINEAR_LINEAR_RATE and shared by every Linear query.
+var linearLimiter *rateLimiter
+
+// githubWorkers is parsed from $BYELINEAR_GITHUB_WORKERS.
+var githubWorkers int
+
+// githubLimiter is parsed from $BYELINEAR_GITHUB_RATE and shared by every GitHub client.
+var githubLimiter *rateLimiter
+
 type state struct {
-	Issues   []*issueState   `json:"issues"`
-	Labels   []string        `json:"labels"`
-	Projects []*projectState `json:"projects"`
+	SchemaVersion int             `json:"schema_version"`
+	Issues        []*issueState   `json:"issues"`
+	Labels        []string        `json:"labels"`
+	Projects      []*projectState `json:"projects"`
+	// LinearCursor is the identifier of the last issue fetched by from-linear without a
+	// filter. Filtered runs do not move it so that an unfiltered run resumes after every
+	// issue it has seen.
+	LinearCursor string `json:"linear_cursor,omitempty"`
+
+	// Indexes of Issues by identifier, Labels and Projects by name. Built by index.
+	issueIndex   map[string]*issueState
+	labelIndex   map[string]bool
+	projectIndex map[string]*projectState
+
+	// mu guards the state while the workers of to-github update it.
+	mu sync.Mutex
+	// projectMus serializes creating each GitHub project without holding mu. Guarded by
+	// mu.
+	projectMus map[string]*sync.Mutex
 }
 
 type issueState struct {
-	ID               string `json:"id"`
-	Identifier       string `json:"identifier"`
-	ExportedToGithub bool   `json:"exported_to_github"`
+	ID         string `json:"id"`
+	Identifier string `json:"identifier"`
+	// CreatedAt orders Issues. It is zero in corpora fetched before it was recorded until
+	// from-linear backfills it from the issue files.
+	CreatedAt        time.Time `json:"created_at"`
+	ExportedToGithub bool      `json:"exported_to_github"`
+	// GithubNumber is set as soon as the issue is created. ExportedToGithub is only set
+	// once its follow-ups are done too. GithubStep is the number of follow-ups done so
+	// that an interrupted export resumes where it left off instead of creating the issue
+	// again.
+	GithubNumber int `json:"github_number,omitempty"`
+	GithubStep   int `json:"github_step,omitempty"`
+	// GithubImportID is the pending import of the issue with $BYELINEAR_GITHUB_IMPORT.
+	GithubImportID int `json:"github_import_id,omitempty"`
+	// ArchivedToMarkdown is set when the issue was written to the archive file instead of
+	// being exported to GitHub.
+	ArchivedToMarkdown bool `json:"archived_to_markdown,omitempty"`
 }
 
 type projectState struct {
 	Name            string           `json:"name"`
-	ID              string           `json:"keyName"`
+	ID              string           `json:"id"`
 	StatusFieldInfo *statusFieldInfo `json:"status_field_info"`
+	DateFieldInfo   *dateFieldInfo   `json:"date_field_info"`
+	// MilestoneFieldInfo is nil if the Linear project has no milestones.
+	MilestoneFieldInfo *milestoneFieldInfo `json:"milestone_field_info"`
+	Closed             bool                `json:"closed"`
 }
 
 func main() {
 	if byelinearCorpus == "" {
 		byelinearCorpus = "linear-corpus"
 	}
+	if byelinearGithubURL == "" {
+		byelinearGithubURL = "https://api.github.com/"
+	}
+	if !strings.HasSuffix(byelinearGithubURL, "/") {
+		byelinearGithubURL += "/"
+	}
+
+	var err error
+	filter, err = parseIssueFilter(byelinearFilter)
+	if err != nil {
+		log.Fatalf("$BYELINEAR_FILTER: %v", err)
+	}
+	if byelinearIssueNumber != "" {
+		number, err := strconv.Atoi(byelinearIssueNumber)
+		if err != nil {
+			log.Fatalf("$BYELINEAR_ISSUE_NUMBER: %v", err)
+		}
+		filter.numbers = append(filter.numbers, number)
+	}
+	policy, err = parseExportPolicy(byelinearPolicy)
+	if err != nil {
+		log.Fatalf("$BYELINEAR_POLICY: %v", err)
+	}
+	switch byelinearCustomers {
+	case "":
+		byelinearCustomers = customersRedact
+	case customersRedact, customersShow:
+	case customersMirror:
+		if byelinearCustomersRepo == "" {
+			log.Fatalf("$BYELINEAR_CUSTOMERS_REPO is required with $BYELINEAR_CUSTOMERS=mirror")
+		}
+	default:
+		log.Fatalf("$BYELINEAR_CUSTOMERS must be one of redact, show or mirror: %q", byelinearCustomers)
+	}
+	err = loadRedactor()
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = loadTemplates()
+	if err != nil {
+		log.Fatal(err)
+	}
+	linearWorkers, err = parseWorkers(byelinearLinearWorkers, 4)
+	if err != nil {
+		log.Fatalf("$BYELINEAR_LINEAR_WORKERS: %v", err)
+	}
+	linearLimiter, err = parseRateLimiter(byelinearLinearRate)
+	if err != nil {
+		log.Fatalf("$BYELINEAR_LINEAR_RATE: %v", err)
+	}
+	githubWorkers, err = parseWorkers(byelinearGithubWorkers, 4)
+	if err != nil {
+		log.Fatalf("$BYELINEAR_GITHUB_WORKERS: %v", err)
+	}
+	githubLimiter, err = parseRateLimiter(byelinearGithubRate)
+	if err != nil {
+		log.Fatalf("$BYELINEAR_GITHUB_RATE: %v", err)
+	}
 
-	err := run()
+	err = run()
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -59,6 +192,27 @@ func run() error {
 	ctx, cancel := context.WithTimeout(ctx, time.Hour*24)
 	defer cancel()
 
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "from-linear", "to-github", "to-docs", "redact", "corpus", "verify", "verify-github", "rollback":
+	default:
+		usage()
+	}
+
+	unlock, err := lockCorpus()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	corpus, err = openCorpus()
+	if err != nil {
+		return err
+	}
+	defer corpus.close()
+
 	s, err := readState()
 	if err != nil {
 		return err
@@ -71,16 +225,23 @@ func run() error {
 	go func() {
 		defer close(done)
 
-		if len(os.Args) < 2 {
-			usage()
-		}
 		switch os.Args[1] {
 		case "from-linear":
 			done <- s.fromLinear(ctx)
 		case "to-github":
 			done <- s.toGithub(ctx)
-		default:
-			usage()
+		case "to-docs":
+			done <- toDocs()
+		case "redact":
+			done <- s.redactReport()
+		case "corpus":
+			done <- s.corpusCmd(ctx, os.Args[2:])
+		case "verify":
+			done <- s.verify()
+		case "verify-github":
+			done <- s.verifyGithub(ctx)
+		case "rollback":
+			done <- s.rollback(ctx, os.Args[2:])
 		}
 	}()
 
@@ -93,45 +254,47 @@ func run() error {
 	return err
 }
 
+// confirm asks the user the yes or no question on stdin and returns whether the answer
+// was yes.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
 func usage() {
 	fmt.Printf(`usage:
-	%s [ from-linear | to-github ]
+	%s [ from-linear | to-github | to-docs | redact | corpus | verify | verify-github | rollback ]
 
 Use from-linear to export issues from linear and to-github to export issues to github.
+Use to-docs to write linear projects, their updates and documents as markdown files.
+Use redact to review what to-github would redact with $BYELINEAR_REDACT.
+Use corpus to inspect the fetched issues. See byelinear corpus for its operations.
+Use verify to check the corpus for missing, truncated or unmapped data before to-github.
+Use verify-github to compare the exported issues on github to the corpus and repair them.
+Use rollback to remove the issues, labels and projects created by to-github.
 See docs and environment variable configuration at https://oss.terrastruct.com/byelinear
 `, os.Args[0])
 	os.Exit(1)
 }
 
 func readState() (*state, error) {
-	sb, err := os.ReadFile(filepath.Join(byelinearCorpus, "state.json"))
-	if os.IsNotExist(err) {
-		return &state{}, nil
-	}
-	if err != nil {
-		return nil, err
-	}
-
-	var s *state
-	err = json.Unmarshal(sb, &s)
-	if err != nil {
-		return nil, err
-	}
-	return s, nil
+	return corpus.readState()
 }
 
+// writeState is safe to call from the workers of to-github.
 func writeState(s *state) error {
-	b, err := json.Marshal(s)
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(filepath.Join(byelinearCorpus, "state.json"), b, 0644)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return corpus.writeState(s)
 }
 
 func (s *state) fetchLinearIssues(ctx context.Context, lc *http.Client, previousID string) (*issueState, error) {
-	ctx, cancel := context.WithTimeout(ctx, time.Minute*2)
-	defer cancel()
-
 	issuesArr, err := queryLinearIssues(ctx, lc, previousID)
 	if err != nil {
 		return nil, err
@@ -140,28 +303,100 @@ func (s *state) fetchLinearIssues(ctx context.Context, lc *http.Client, previous
 		return nil, nil
 	}
 
-	var iss *issueState
+	var matched []*linearIssue
 	for _, liss := range issuesArr {
-		b, err := json.Marshal(liss)
-		if err != nil {
-			return nil, err
+		if filter.match(liss) {
+			matched = append(matched, liss)
 		}
+	}
+	err = hydrateLinearIssues(ctx, lc, matched)
+	if err != nil {
+		return nil, err
+	}
 
-		dest := filepath.Join(byelinearCorpus, liss.Identifier+".json")
-		err = os.WriteFile(dest, b, 0644)
-		if err != nil {
-			return nil, err
+	// The issues are added to the state once the whole page is written so that the
+	// cursor is only saved after every issue before it.
+	for _, liss := range matched {
+		iss, ok := s.issue(liss.Identifier)
+		if !ok {
+			s.insertIssue(&issueState{
+				ID:               liss.ID,
+				Identifier:       liss.Identifier,
+				CreatedAt:        liss.CreatedAt,
+				ExportedToGithub: false,
+			})
+		} else if iss.CreatedAt.IsZero() {
+			iss.CreatedAt = liss.CreatedAt
 		}
+	}
+
+	// The last issue of the page is the cursor even if it did not match the filter.
+	last := issuesArr[len(issuesArr)-1]
+	return &issueState{
+		ID:         last.ID,
+		Identifier: last.Identifier,
+	}, nil
+}
 
-		iss = &issueState{
-			ID:               liss.ID,
-			Identifier:       liss.Identifier,
-			ExportedToGithub: false,
+// hydrateLinearIssues fetches the rest of each issue and writes it to the corpus with
+// linearWorkers concurrent workers.
+func hydrateLinearIssues(ctx context.Context, lc *http.Client, issues []*linearIssue) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	work := make(chan *linearIssue)
+	errs := make(chan error, linearWorkers)
+	var wg sync.WaitGroup
+	for i := 0; i < linearWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for liss := range work {
+				err := hydrateLinearIssue(ctx, lc, liss)
+				if err != nil {
+					errs <- fmt.Errorf("%s: %w", liss.Identifier, err)
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, liss := range issues {
+		select {
+		case work <- liss:
+		case <-ctx.Done():
+			break feed
 		}
-		s.Issues = append(s.Issues, iss)
 	}
+	close(work)
+	wg.Wait()
 
-	return iss, nil
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return ctx.Err()
+	}
+}
+
+func hydrateLinearIssue(ctx context.Context, lc *http.Client, liss *linearIssue) error {
+	err := liss.fetchRemainingComments(ctx, lc)
+	if err != nil {
+		return err
+	}
+	err = liss.fetchHistory(ctx, lc)
+	if err != nil {
+		return err
+	}
+
+	liss.SchemaVersion = corpusSchemaVersion
+	b, err := json.Marshal(liss)
+	if err != nil {
+		return err
+	}
+	return corpus.writeIssue(liss.Identifier, b)
 }
 
 func (s *state) fromLinear(ctx context.Context) error {
@@ -177,21 +412,27 @@ func (s *state) fromLinear(ctx context.Context) error {
 	// 	))
 	// }
 
+	err = s.backfillCreatedAt()
+	if err != nil {
+		return err
+	}
+
 	iss := &issueState{
 		ID:         "",
 		Identifier: "",
 	}
-	if byelinearIssueNumber == "" && len(s.Issues) > 0 {
-		iss = s.Issues[len(s.Issues)-1]
+	// A filtered fetch always starts from the beginning as the issues it skipped have not
+	// been fetched.
+	unfiltered := byelinearFilter == "" && byelinearIssueNumber == ""
+	if unfiltered {
+		if cursorIss, ok := s.issue(s.LinearCursor); ok {
+			iss = cursorIss
+		} else if len(s.Issues) > 0 {
+			log.Print("no cursor in the corpus: fetching every issue again in case earlier filtered runs skipped some")
+		}
 	}
 	for {
-		if byelinearIssueNumber != "" {
-			if strings.HasSuffix(iss.Identifier, "-"+byelinearIssueNumber) {
-				log.Printf("fetched %s", byelinearIssueNumber)
-				return nil
-			}
-			log.Printf("fetching %s", byelinearIssueNumber)
-		} else if iss.Identifier != "" {
+		if iss.Identifier != "" {
 			log.Printf("fetching 50 after %s", iss.Identifier)
 		} else {
 			log.Print("fetching oldest 50")
@@ -209,9 +450,18 @@ func (s *state) fromLinear(ctx context.Context) error {
 
 		if cursorIss == nil {
 			log.Print("all linear issues fetched successfully")
+			log.Print("fetching projects")
+			err = fetchLinearProjects(ctx, lc)
+			if err != nil {
+				return err
+			}
+			log.Print("all linear projects fetched successfully")
 			return nil
 		}
 
+		if unfiltered {
+			s.LinearCursor = cursorIss.Identifier
+		}
 		err = writeState(s)
 		if err != nil {
 			return err
@@ -228,29 +478,85 @@ func (s *state) fromLinear(ctx context.Context) error {
 }
 
 func (s *state) toGithub(ctx context.Context) error {
-	if orgName == "" {
-		log.Fatalf("$BYELINEAR_ORG is required")
+	gc, err := githubClientFromEnv(ctx)
+	if err != nil {
+		return err
 	}
-	if repoName == "" {
-		log.Fatalf("$BYELINEAR_REPO is required")
+	err = loadGithubUserClients(ctx)
+	if err != nil {
+		return err
 	}
 
-	gchttp := http.DefaultClient
-	if githubToken != "" {
-		gchttp = oauth2.NewClient(ctx, oauth2.StaticTokenSource(
-			&oauth2.Token{AccessToken: githubToken},
-		))
+	err = readLinearProjects()
+	if err != nil {
+		return err
+	}
+	err = policy.printSummary(s)
+	if err != nil {
+		return err
+	}
+	rr, err := readRedactionReport()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	exports := make(chan *githubExport, githubWorkers)
+	errs := make(chan error, githubWorkers)
+	var wg sync.WaitGroup
+	for i := 0; i < githubWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range exports {
+				err := s.finishExport(ctx, gc, e)
+				if err != nil {
+					errs <- err
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+
+	err = s.createGithubIssues(ctx, gc, rr, exports)
+	close(exports)
+	wg.Wait()
+	select {
+	case err := <-errs:
+		return err
+	default:
+	}
+	if err != nil {
+		return err
+	}
+	return s.closeCompletedProjects(ctx, gc.Client())
+}
+
+// createGithubIssues creates the issues in order and sends them to exports for their
+// follow-ups.
+func (s *state) createGithubIssues(ctx context.Context, gc *github.Client, rr redactionReport, exports chan<- *githubExport) error {
+	var na *numberAligner
+	if byelinearPreserveNumbers != "" {
+		var err error
+		na, err = s.newNumberAligner(ctx, gc)
+		if err != nil {
+			return err
+		}
 	}
-	gc := github.NewClient(gchttp)
 
 	for _, iss := range s.Issues {
-		if byelinearIssueNumber != "" && !strings.HasSuffix(iss.Identifier, "-"+byelinearIssueNumber) {
+		if !filter.matchIdentifier(iss.Identifier) {
 			continue
 		}
 		liss, err := iss.linear()
 		if err != nil {
 			return err
 		}
+		if !filter.match(liss) {
+			continue
+		}
 		if liss.Creator == nil {
 			log.Printf("%s: skipped tutorial issue", iss.Identifier)
 			continue
@@ -259,29 +565,108 @@ func (s *state) toGithub(ctx context.Context) error {
 			log.Printf("%s: skipped already exported issue", iss.Identifier)
 			continue
 		}
+		if iss.ArchivedToMarkdown {
+			log.Printf("%s: skipped already archived issue", iss.Identifier)
+			continue
+		}
+		switch policy.action(liss) {
+		case policySkip:
+			log.Printf("%s: skipped %s issue", iss.Identifier, policy.bucket(liss))
+			continue
+		case policyArchive:
+			giss, err := fromLinearIssue(liss, true)
+			if err != nil {
+				return err
+			}
+			ok, err := rr.redactIssue(iss.Identifier, giss)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				log.Printf("%s: skipped blocked issue: see %s", iss.Identifier, filepath.Join(byelinearCorpus, "redactions.json"))
+				continue
+			}
+			err = archiveToMarkdown(liss, giss)
+			if err != nil {
+				return err
+			}
+			s.mu.Lock()
+			iss.ArchivedToMarkdown = true
+			s.mu.Unlock()
+			err = writeState(s)
+			if err != nil {
+				return err
+			}
+			log.Printf("%s: archived %s issue to %s", iss.Identifier, policy.bucket(liss), archivePath())
+			continue
+		}
 
-		log.Printf("%s: exporting", iss.Identifier)
+		giss, err := fromLinearIssue(liss, withAuthor(liss))
+		if err != nil {
+			return err
+		}
+		ok, err := rr.redactIssue(iss.Identifier, giss)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			log.Printf("%s: skipped blocked issue: see %s", iss.Identifier, filepath.Join(byelinearCorpus, "redactions.json"))
+			continue
+		}
 
-		for {
-			url, err := s.exportToGithub(ctx, gc, iss.Identifier, fromLinearIssue(liss))
+		e := &githubExport{state: iss, iss: giss}
+		if iss.GithubNumber != 0 {
+			log.Printf("%s: resuming export of #%d", iss.Identifier, iss.GithubNumber)
+			err = e.resume(ctx, gc)
 			if err != nil {
-				log.Printf("%s: failed to export (retrying in 5 minutes): %v", iss.Identifier, err)
-				select {
-				case <-ctx.Done():
-					return ctx.Err()
-				case <-time.After(time.Minute * 5):
-					continue
-				}
+				return err
+			}
+			select {
+			case exports <- e:
+			case <-ctx.Done():
+				return ctx.Err()
 			}
+			continue
+		}
 
-			iss.ExportedToGithub = true
-			err = writeState(s)
-			if err != nil {
+		log.Printf("%s: exporting", iss.Identifier)
+		for {
+			err = nil
+			// The numbers were aligned before a pending import was started.
+			if na != nil && iss.GithubImportID == 0 {
+				err = na.prepare(ctx, gc, iss.Identifier)
+			}
+			if err == nil {
+				err = s.createGithubIssue(ctx, gc, e)
+			}
+			if err == nil {
+				s.mu.Lock()
+				iss.GithubNumber = e.number
+				iss.GithubImportID = 0
+				s.mu.Unlock()
+				err = writeState(s)
+			}
+			if err == nil && na != nil {
+				err = na.created(iss.Identifier, e.number)
+			}
+			if isNumberingError(err) {
 				return err
 			}
+			if err == nil {
+				break
+			}
+			log.Printf("%s: failed to export (retrying in 5 minutes): %v", iss.Identifier, err)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Minute * 5):
+			}
+		}
 
-			log.Printf("%s: exported: %s", iss.Identifier, url)
-			break
+		select {
+		case exports <- e:
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 
 		select {
@@ -294,9 +679,145 @@ func (s *state) toGithub(ctx context.Context) error {
 	return nil
 }
 
+// finishExport runs the follow-ups of the created issue until they succeed and then
+// records the issue as exported.
+func (s *state) finishExport(ctx context.Context, gc *github.Client, e *githubExport) error {
+	for {
+		err := s.finishGithubIssue(ctx, gc, e)
+		if err == nil {
+			break
+		}
+		log.Printf("%s: failed to finish export (retrying in 5 minutes): %v", e.ident(), err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Minute * 5):
+		}
+	}
+
+	s.mu.Lock()
+	e.state.ExportedToGithub = true
+	e.state.GithubStep = 0
+	s.mu.Unlock()
+	err := writeState(s)
+	if err != nil {
+		return err
+	}
+	log.Printf("%s: exported: %s", e.ident(), e.url)
+	return nil
+}
+
+// githubClientFromEnv returns the client for $BYELINEAR_ORG/$BYELINEAR_REPO authenticated
+// with $GITHUB_TOKEN.
+func githubClientFromEnv(ctx context.Context) (*github.Client, error) {
+	if orgName == "" {
+		return nil, errors.New("$BYELINEAR_ORG is required")
+	}
+	if repoName == "" {
+		return nil, errors.New("$BYELINEAR_REPO is required")
+	}
+
+	gchttp := http.DefaultClient
+	if githubToken != "" {
+		gchttp = oauth2.NewClient(ctx, oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: githubToken},
+		))
+	}
+	return newGithubClient(gchttp)
+}
+
+// closeCompletedProjects closes the GitHub projects of completed Linear projects once
+// all their issues have been exported or skipped so that a project exported in parts with
+// a filter stays open until its last part.
+func (s *state) closeCompletedProjects(ctx context.Context, hc *http.Client) error {
+	var completed []*projectState
+	for _, p := range s.Projects {
+		lp, ok := linearProjects[p.Name]
+		if !ok || lp.State != "completed" || p.Closed {
+			continue
+		}
+		completed = append(completed, p)
+	}
+	if len(completed) == 0 {
+		return nil
+	}
+	pending, err := s.pendingProjects()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range completed {
+		if pending[p.Name] {
+			log.Printf("not closing completed project with issues left to export: %s", p.Name)
+			continue
+		}
+		log.Printf("closing completed project: %s", p.Name)
+		err := closeProject(ctx, hc, p.ID)
+		if err != nil {
+			return err
+		}
+		p.Closed = true
+		err = writeState(s)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pendingProjects returns the names of the projects with issues in the corpus that
+// to-github would still export.
+func (s *state) pendingProjects() (map[string]bool, error) {
+	pending := make(map[string]bool)
+	for _, iss := range s.Issues {
+		if iss.ExportedToGithub || iss.ArchivedToMarkdown {
+			continue
+		}
+		liss, err := iss.linear()
+		if err != nil {
+			return nil, err
+		}
+		if liss.Project.Name == "" || pending[liss.Project.Name] {
+			continue
+		}
+		if liss.Creator == nil || policy.action(liss) != policyImport {
+			continue
+		}
+		if redactor != nil {
+			giss, err := fromLinearIssue(liss, withAuthor(liss))
+			if err != nil {
+				return nil, err
+			}
+			if _, blocked := redactor.redact(giss); blocked {
+				continue
+			}
+		}
+		pending[liss.Project.Name] = true
+	}
+	return pending, nil
+}
+
+// backfillCreatedAt sets CreatedAt of the issues fetched before it was recorded from their
+// issue files.
+func (s *state) backfillCreatedAt() error {
+	for _, iss := range s.Issues {
+		if !iss.CreatedAt.IsZero() {
+			continue
+		}
+		liss, err := iss.linear()
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		iss.CreatedAt = liss.CreatedAt
+	}
+	return nil
+}
+
 func (is *issueState) linear() (*linearIssue, error) {
-	file := filepath.Join(byelinearCorpus, is.Identifier+".json")
-	b, err := os.ReadFile(file)
+	b, err := corpus.readIssue(is.Identifier)
 	if err != nil {
 		return nil, err
 	}
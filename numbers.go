@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/go-github/v47/github"
+)
+
+// numberAligner creates placeholder issues so that each Linear issue is created with its
+// Linear number when $BYELINEAR_PRESERVE_NUMBERS is set.
+type numberAligner struct {
+	team string
+	// next is the number GitHub gives the next issue or pull request.
+	next int
+}
+
+// numberingError means the issue numbers can no longer be aligned. to-github aborts
+// instead of retrying.
+type numberingError struct {
+	msg string
+}
+
+func (e *numberingError) Error() string {
+	return "$BYELINEAR_PRESERVE_NUMBERS: " + e.msg
+}
+
+func numberingErrorf(format string, v ...interface{}) error {
+	return &numberingError{msg: fmt.Sprintf(format, v...)}
+}
+
+func isNumberingError(err error) bool {
+	var nerr *numberingError
+	return errors.As(err, &nerr)
+}
+
+// newNumberAligner checks that the remaining issues can be created with their Linear
+// numbers in $BYELINEAR_ORG/$BYELINEAR_REPO.
+func (s *state) newNumberAligner(ctx context.Context, gc *github.Client) (*numberAligner, error) {
+	na := &numberAligner{next: 1}
+	latest, _, err := gc.Issues.ListByRepo(ctx, orgName, repoName, &github.IssueListByRepoOptions{
+		State:       "all",
+		Sort:        "created",
+		Direction:   "desc",
+		ListOptions: github.ListOptions{PerPage: 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(latest) > 0 {
+		na.next = latest[0].GetNumber() + 1
+	}
+
+	// Issues are created in corpus order so their numbers must increase.
+	last := na.next - 1
+	var lastIdent string
+	for _, iss := range s.Issues {
+		if iss.ExportedToGithub || iss.ArchivedToMarkdown || !filter.matchIdentifier(iss.Identifier) {
+			continue
+		}
+		liss, err := iss.linear()
+		if err != nil {
+			return nil, err
+		}
+		if liss.Creator == nil || !filter.match(liss) || policy.action(liss) != policyImport {
+			continue
+		}
+		if na.team == "" {
+			na.team = liss.Team.Key
+		} else if liss.Team.Key != na.team {
+			return nil, numberingErrorf("%s is not in team %s: use team= in $BYELINEAR_FILTER to export one team", iss.Identifier, na.team)
+		}
+		n, err := identifierNumber(iss.Identifier)
+		if err != nil {
+			return nil, err
+		}
+		if iss.GithubNumber != 0 {
+			// Created by an interrupted run and only its follow-ups are left.
+			if iss.GithubNumber != n {
+				return nil, numberingErrorf("%s was created as #%d instead of #%d", iss.Identifier, iss.GithubNumber, n)
+			}
+			continue
+		}
+		if iss.GithubImportID != 0 {
+			// Imported by an interrupted run and checked by created once the import is
+			// done.
+			continue
+		}
+		if n <= last {
+			if lastIdent != "" {
+				return nil, numberingErrorf("%s cannot be created as #%d after %s", iss.Identifier, n, lastIdent)
+			}
+			return nil, numberingErrorf("%s cannot be created as #%d: the next issue number is %d", iss.Identifier, n, na.next)
+		}
+		last = n
+		lastIdent = iss.Identifier
+	}
+	log.Printf("the next issue number of %s/%s is %d", orgName, repoName, na.next)
+	return na, nil
+}
+
+// prepare creates placeholder issues until the next issue number is the number of ident.
+func (na *numberAligner) prepare(ctx context.Context, gc *github.Client, ident string) error {
+	want, err := identifierNumber(ident)
+	if err != nil {
+		return err
+	}
+	if want < na.next {
+		return numberingErrorf("%s cannot be created as #%d: the next issue number is %d", ident, want, na.next)
+	}
+	for na.next < want {
+		num, err := createPlaceholderIssue(ctx, gc, ident)
+		if err != nil {
+			return err
+		}
+		if num >= want {
+			return numberingErrorf("placeholder was created as #%d before %s: was another issue, pull request or a deleted issue created meanwhile?", num, ident)
+		}
+		na.next = num + 1
+	}
+	return nil
+}
+
+// created checks that ident was created with its number.
+func (na *numberAligner) created(ident string, num int) error {
+	want, err := identifierNumber(ident)
+	if err != nil {
+		return err
+	}
+	if num != want {
+		return numberingErrorf("%s was created as #%d instead of #%d: was another issue, pull request or a deleted issue created meanwhile?", ident, num, want)
+	}
+	na.next = num + 1
+	return nil
+}
+
+// createPlaceholderIssue creates a closed and locked issue to take up a number missing
+// from Linear.
+func createPlaceholderIssue(ctx context.Context, gc *github.Client, before string) (int, error) {
+	title := "Placeholder"
+	body := "Placeholder that keeps the GitHub issue numbers aligned with the Linear issue numbers."
+	giss, _, err := gc.Issues.Create(ctx, orgName, repoName, &github.IssueRequest{
+		Title: &title,
+		Body:  &body,
+	})
+	if err != nil {
+		return 0, err
+	}
+	num := giss.GetNumber()
+	log.Printf("%s: created placeholder #%d", before, num)
+	err = recordOperation(&journalEntry{
+		Kind:   journalIssue,
+		Repo:   orgName + "/" + repoName,
+		Number: num,
+	})
+	if err != nil {
+		return 0, err
+	}
+	_, _, err = gc.Issues.Edit(ctx, orgName, repoName, num, &github.IssueRequest{
+		State:       github.String("closed"),
+		StateReason: github.String("not_planned"),
+	})
+	if err != nil {
+		return 0, err
+	}
+	_, err = gc.Issues.Lock(ctx, orgName, repoName, num, &github.LockIssueOptions{
+		LockReason: "resolved",
+	})
+	if err != nil {
+		return 0, err
+	}
+	return num, nil
+}
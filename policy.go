@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	policyImport  = "import"
+	policySkip    = "skip"
+	policyArchive = "archive"
+)
+
+const (
+	bucketActive    = "active"
+	bucketArchived  = "archived"
+	bucketCompleted = "completed"
+	bucketCanceled  = "canceled"
+	bucketBacklog   = "backlog"
+)
+
+// exportPolicy is parsed from $BYELINEAR_POLICY and decides what to-github does with
+// each bucket of issues. Issues in the backlog bucket are backlog issues created more
+// than backlogDays ago.
+type exportPolicy struct {
+	actions     map[string]string
+	backlogDays int
+}
+
+func parseExportPolicy(s string) (*exportPolicy, error) {
+	p := &exportPolicy{
+		actions: map[string]string{
+			bucketActive:    policyImport,
+			bucketArchived:  policyImport,
+			bucketCompleted: policyImport,
+			bucketCanceled:  policyImport,
+			bucketBacklog:   policyImport,
+		},
+	}
+	for _, term := range strings.Fields(s) {
+		i := strings.IndexByte(term, '=')
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid policy term %q: expected bucket=action", term)
+		}
+		key, val := term[:i], term[i+1:]
+		if key == "backlog-days" {
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid policy term %q: expected a number of days", term)
+			}
+			p.backlogDays = n
+			continue
+		}
+		if _, ok := p.actions[key]; !ok || key == bucketActive {
+			return nil, fmt.Errorf("invalid policy term %q: unknown bucket %q", term, key)
+		}
+		switch val {
+		case policyImport, policySkip, policyArchive:
+		default:
+			return nil, fmt.Errorf("invalid policy term %q: action must be one of import, skip or archive", term)
+		}
+		p.actions[key] = val
+	}
+	return p, nil
+}
+
+func (p *exportPolicy) bucket(liss *linearIssue) string {
+	if liss.ArchivedAt != nil {
+		return bucketArchived
+	}
+	switch liss.stateType() {
+	case "completed":
+		return bucketCompleted
+	case "canceled":
+		return bucketCanceled
+	case "backlog":
+		if p.backlogDays > 0 && time.Since(liss.CreatedAt) > time.Duration(p.backlogDays)*24*time.Hour {
+			return bucketBacklog
+		}
+	}
+	return bucketActive
+}
+
+func (p *exportPolicy) action(liss *linearIssue) string {
+	return p.actions[p.bucket(liss)]
+}
+
+// printSummary logs how many of the remaining issues fall into each bucket and what will
+// be done with them.
+func (p *exportPolicy) printSummary(s *state) error {
+	counts := map[string]int{}
+	for _, iss := range s.Issues {
+		if iss.ExportedToGithub || iss.ArchivedToMarkdown || !filter.matchIdentifier(iss.Identifier) {
+			continue
+		}
+		liss, err := iss.linear()
+		if err != nil {
+			return err
+		}
+		if liss.Creator == nil || !filter.match(liss) {
+			continue
+		}
+		counts[p.bucket(liss)]++
+	}
+
+	var buckets []string
+	for b := range counts {
+		buckets = append(buckets, b)
+	}
+	sort.Strings(buckets)
+	for _, b := range buckets {
+		log.Printf("%s: %d issues (%s)", b, counts[b], p.actions[b])
+	}
+	return nil
+}
+
+// archiveToMarkdown appends the redacted issue and its comments to the archive Markdown
+// file.
+func archiveToMarkdown(liss *linearIssue, iss *githubIssue) error {
+	f, err := os.OpenFile(archivePath(), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	md := fmt.Sprintf("## [%s](%s)\n\n%s\n", iss.title, liss.URL, iss.body)
+	for i, c := range iss.comments {
+		body, err := iss.commentBody(c, true, nil)
+		if err != nil {
+			return err
+		}
+		md += fmt.Sprintf("\n### Comment %d\n\n%s\n", i, body)
+	}
+	if iss.timeline != "" {
+		md += "\n" + iss.timeline + "\n"
+	}
+	md += "\n---\n\n"
+	_, err = f.WriteString(md)
+	if err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+func archivePath() string {
+	if byelinearArchive != "" {
+		return byelinearArchive
+	}
+	return filepath.Join(byelinearCorpus, "archive.md")
+}
+
+// stateType returns the type of the issue's workflow state. Corpora fetched before the
+// type was fetched fall back to the default state names.
+func (li *linearIssue) stateType() string {
+	if li.State.Type != "" {
+		return li.State.Type
+	}
+	switch li.State.Name {
+	case "Done":
+		return "completed"
+	case "Canceled":
+		return "canceled"
+	case "Backlog":
+		return "backlog"
+	}
+	return ""
+}
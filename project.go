@@ -0,0 +1,542 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type linearProject struct {
+	ID          string      `json:"id"`
+	SlugID      string      `json:"slugId"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Content     string      `json:"content"`
+	Icon        string      `json:"icon"`
+	Color       string      `json:"color"`
+	State       string      `json:"state"`
+	StartDate   string      `json:"startDate"`
+	TargetDate  string      `json:"targetDate"`
+	Lead        *linearUser `json:"lead"`
+	Members     struct {
+		Nodes []*linearUser `json:"nodes"`
+	} `json:"members"`
+	ProjectUpdates struct {
+		PageInfo linearPageInfo         `json:"pageInfo"`
+		Nodes    []*linearProjectUpdate `json:"nodes"`
+	} `json:"projectUpdates"`
+	Documents struct {
+		PageInfo linearPageInfo    `json:"pageInfo"`
+		Nodes    []*linearDocument `json:"nodes"`
+	} `json:"documents"`
+	ProjectMilestones struct {
+		Nodes []struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			TargetDate  string `json:"targetDate"`
+		} `json:"nodes"`
+	} `json:"projectMilestones"`
+}
+
+type linearDocument struct {
+	ID        string      `json:"id"`
+	Title     string      `json:"title"`
+	Content   string      `json:"content"`
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+	Creator   *linearUser `json:"creator"`
+}
+
+type linearProjectUpdate struct {
+	Body      string      `json:"body"`
+	Health    string      `json:"health"`
+	CreatedAt time.Time   `json:"createdAt"`
+	User      *linearUser `json:"user"`
+}
+
+const linearDocumentFields = `id
+						title
+						content
+						createdAt
+						updatedAt
+						creator {
+							name
+							email
+						}`
+
+const linearProjectUpdateFields = `body
+						health
+						createdAt
+						user {
+							name
+							email
+						}`
+
+func queryLinearProjects(ctx context.Context, hc *http.Client, after string) ([]*linearProject, linearPageInfo, error) {
+	queryString := `query($after: String) {
+		projects(first: 25, after: $after, includeArchived: true) {
+			pageInfo {
+				hasNextPage
+				endCursor
+			}
+			nodes {
+				id
+				slugId
+				name
+				description
+				content
+				icon
+				color
+				state
+				startDate
+				targetDate
+				lead {
+					name
+					email
+				}
+				members {
+					nodes {
+						name
+						email
+					}
+				}
+				projectUpdates(first: 50) {
+					pageInfo {
+						hasNextPage
+						endCursor
+					}
+					nodes {
+						` + linearProjectUpdateFields + `
+					}
+				}
+				documents(first: 50) {
+					pageInfo {
+						hasNextPage
+						endCursor
+					}
+					nodes {
+						` + linearDocumentFields + `
+					}
+				}
+				projectMilestones {
+					nodes {
+						name
+						description
+						targetDate
+					}
+				}
+			}
+		}
+	}`
+	var queryResp struct {
+		Data struct {
+			Projects struct {
+				PageInfo linearPageInfo   `json:"pageInfo"`
+				Nodes    []*linearProject `json:"nodes"`
+			} `json:"projects"`
+		} `json:"data"`
+	}
+
+	qreq := &graphqlQuery{
+		Query:     queryString,
+		Variables: map[string]interface{}{"after": after},
+	}
+	err := doLinearQuery(ctx, hc, qreq, &queryResp)
+	if err != nil {
+		return nil, linearPageInfo{}, err
+	}
+	return queryResp.Data.Projects.Nodes, queryResp.Data.Projects.PageInfo, nil
+}
+
+// fetchLinearProjects writes every Linear project to projects.json in the corpus.
+func fetchLinearProjects(ctx context.Context, hc *http.Client) error {
+	var projects []*linearProject
+	pageInfo := linearPageInfo{HasNextPage: true}
+	for pageInfo.HasNextPage {
+		var page []*linearProject
+		var err error
+		page, pageInfo, err = queryLinearProjects(ctx, hc, pageInfo.EndCursor)
+		if err != nil {
+			return err
+		}
+		projects = append(projects, page...)
+	}
+	for _, lp := range projects {
+		err := lp.fetchRemainingUpdates(ctx, hc)
+		if err != nil {
+			return err
+		}
+		err = lp.fetchRemainingDocuments(ctx, hc)
+		if err != nil {
+			return err
+		}
+	}
+
+	b, err := json.Marshal(projects)
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(byelinearCorpus, "projects.json"), b)
+}
+
+func queryLinearProjectUpdates(ctx context.Context, hc *http.Client, projectID, after string) ([]*linearProjectUpdate, linearPageInfo, error) {
+	queryString := `query($id: String!, $after: String) {
+		project(id: $id) {
+			projectUpdates(first: 100, after: $after) {
+				pageInfo {
+					hasNextPage
+					endCursor
+				}
+				nodes {
+					` + linearProjectUpdateFields + `
+				}
+			}
+		}
+	}`
+	var queryResp struct {
+		Data struct {
+			Project struct {
+				ProjectUpdates struct {
+					PageInfo linearPageInfo         `json:"pageInfo"`
+					Nodes    []*linearProjectUpdate `json:"nodes"`
+				} `json:"projectUpdates"`
+			} `json:"project"`
+		} `json:"data"`
+	}
+
+	qreq := &graphqlQuery{
+		Query:     queryString,
+		Variables: map[string]interface{}{"id": projectID, "after": after},
+	}
+	err := doLinearQuery(ctx, hc, qreq, &queryResp)
+	if err != nil {
+		return nil, linearPageInfo{}, err
+	}
+	return queryResp.Data.Project.ProjectUpdates.Nodes, queryResp.Data.Project.ProjectUpdates.PageInfo, nil
+}
+
+// fetchRemainingUpdates fetches the updates that did not fit in the first page of the
+// projects query and sorts all updates from oldest to newest.
+func (lp *linearProject) fetchRemainingUpdates(ctx context.Context, hc *http.Client) error {
+	for lp.ProjectUpdates.PageInfo.HasNextPage {
+		updates, pageInfo, err := queryLinearProjectUpdates(ctx, hc, lp.ID, lp.ProjectUpdates.PageInfo.EndCursor)
+		if err != nil {
+			return err
+		}
+		lp.ProjectUpdates.Nodes = append(lp.ProjectUpdates.Nodes, updates...)
+		lp.ProjectUpdates.PageInfo = pageInfo
+	}
+	sort.SliceStable(lp.ProjectUpdates.Nodes, func(i, j int) bool {
+		return lp.ProjectUpdates.Nodes[i].CreatedAt.Before(lp.ProjectUpdates.Nodes[j].CreatedAt)
+	})
+	return nil
+}
+
+func queryLinearProjectDocuments(ctx context.Context, hc *http.Client, projectID, after string) ([]*linearDocument, linearPageInfo, error) {
+	queryString := `query($id: String!, $after: String) {
+		project(id: $id) {
+			documents(first: 50, after: $after) {
+				pageInfo {
+					hasNextPage
+					endCursor
+				}
+				nodes {
+					` + linearDocumentFields + `
+				}
+			}
+		}
+	}`
+	var queryResp struct {
+		Data struct {
+			Project struct {
+				Documents struct {
+					PageInfo linearPageInfo    `json:"pageInfo"`
+					Nodes    []*linearDocument `json:"nodes"`
+				} `json:"documents"`
+			} `json:"project"`
+		} `json:"data"`
+	}
+
+	qreq := &graphqlQuery{
+		Query:     queryString,
+		Variables: map[string]interface{}{"id": projectID, "after": after},
+	}
+	err := doLinearQuery(ctx, hc, qreq, &queryResp)
+	if err != nil {
+		return nil, linearPageInfo{}, err
+	}
+	return queryResp.Data.Project.Documents.Nodes, queryResp.Data.Project.Documents.PageInfo, nil
+}
+
+// fetchRemainingDocuments fetches the documents that did not fit in the first page of the
+// projects query.
+func (lp *linearProject) fetchRemainingDocuments(ctx context.Context, hc *http.Client) error {
+	for lp.Documents.PageInfo.HasNextPage {
+		docs, pageInfo, err := queryLinearProjectDocuments(ctx, hc, lp.ID, lp.Documents.PageInfo.EndCursor)
+		if err != nil {
+			return err
+		}
+		lp.Documents.Nodes = append(lp.Documents.Nodes, docs...)
+		lp.Documents.PageInfo = pageInfo
+	}
+	return nil
+}
+
+// linearProjects is read from projects.json in the corpus by to-github.
+var linearProjects map[string]*linearProject
+
+// readLinearProjects reads projects.json from the corpus. Corpora fetched before projects
+// were fetched have no projects.json.
+func readLinearProjects() error {
+	b, err := os.ReadFile(filepath.Join(byelinearCorpus, "projects.json"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var projects []*linearProject
+	err = json.Unmarshal(b, &projects)
+	if err != nil {
+		return err
+	}
+	linearProjects = make(map[string]*linearProject, len(projects))
+	for _, lp := range projects {
+		linearProjects[lp.Name] = lp
+	}
+	return nil
+}
+
+func (lp *linearProject) milestones() []string {
+	var a []string
+	for _, m := range lp.ProjectMilestones.Nodes {
+		a = append(a, m.Name)
+	}
+	return a
+}
+
+// readme returns the README of the GitHub project: the Linear project's overview
+// followed by its updates.
+func (lp *linearProject) readme() string {
+	readme := lp.overview()
+	if updates := lp.updatesMarkdown(); updates != "" {
+		readme += "\n## Updates\n" + updates
+	}
+	return readme
+}
+
+// overview returns the Linear project's content, a table of its metadata and its
+// milestones.
+func (lp *linearProject) overview() string {
+	var members []string
+	for _, m := range lp.Members.Nodes {
+		members = append(members, formatLinearUser(m))
+	}
+	var lead string
+	if lp.Lead != nil {
+		lead = formatLinearUser(lp.Lead)
+	}
+
+	overview := lp.Content
+	if overview == "" {
+		overview = lp.Description
+	}
+	overview += fmt.Sprintf(`
+
+## Details
+
+field | value
+| - | - |
+lead | %s
+members | %s
+status | %s
+start date | %s
+target date | %s
+icon | %s
+color | %s
+`,
+		lead,
+		strings.Join(members, ", "),
+		lp.State,
+		lp.StartDate,
+		lp.TargetDate,
+		lp.Icon,
+		lp.Color,
+	)
+
+	if len(lp.ProjectMilestones.Nodes) > 0 {
+		overview += "\n## Milestones\n\n"
+		for _, m := range lp.ProjectMilestones.Nodes {
+			overview += fmt.Sprintf("- **%s**", m.Name)
+			if m.TargetDate != "" {
+				overview += " (" + m.TargetDate + ")"
+			}
+			if m.Description != "" {
+				overview += ": " + m.Description
+			}
+			overview += "\n"
+		}
+	}
+	return overview
+}
+
+// updatesMarkdown returns the project's updates from oldest to newest or "" if there are
+// none.
+func (lp *linearProject) updatesMarkdown() string {
+	updates := append([]*linearProjectUpdate(nil), lp.ProjectUpdates.Nodes...)
+	sort.Slice(updates, func(i, j int) bool {
+		return updates[i].CreatedAt.Before(updates[j].CreatedAt)
+	})
+	var md string
+	for _, u := range updates {
+		md += fmt.Sprintf("\n### %s by %s (%s)\n\n%s\n", formatTime(u.CreatedAt), formatLinearUser(u.User), u.Health, u.Body)
+	}
+	return md
+}
+
+type milestoneFieldInfo struct {
+	ID        string            `json:"id"`
+	OptionIDs map[string]string `json:"option_ids"`
+}
+
+// ensureMilestoneField returns the Milestone single select field of the project creating
+// it with an option per milestone if it does not exist.
+func ensureMilestoneField(ctx context.Context, hc *http.Client, projectID string, milestones []string) (*milestoneFieldInfo, error) {
+	queryString := `query($projectId: ID!) {
+		node(id: $projectId) {
+			... on ProjectV2 {
+				field(name: "Milestone") {
+					... on ProjectV2SingleSelectField {
+						id
+						options {
+							id
+							name
+						}
+					}
+				}
+			}
+		}
+	}`
+	var queryResp struct {
+		Data struct {
+			Node struct {
+				Field *singleSelectField `json:"field"`
+			} `json:"node"`
+		} `json:"data"`
+	}
+	qreq := &graphqlQuery{
+		Query:     queryString,
+		Variables: map[string]interface{}{"projectId": projectID},
+	}
+	err := doGithubQuery(ctx, hc, qreq, &queryResp)
+	if err != nil {
+		return nil, err
+	}
+	f := queryResp.Data.Node.Field
+	if f == nil || f.ID == "" {
+		f, err = createMilestoneField(ctx, hc, projectID, milestones)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	mi := &milestoneFieldInfo{
+		ID:        f.ID,
+		OptionIDs: make(map[string]string),
+	}
+	for _, o := range f.Options {
+		mi.OptionIDs[o.Name] = o.ID
+	}
+	return mi, nil
+}
+
+type singleSelectField struct {
+	ID      string `json:"id"`
+	Options []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"options"`
+}
+
+func createMilestoneField(ctx context.Context, hc *http.Client, projectID string, milestones []string) (*singleSelectField, error) {
+	queryString := `mutation($projectId: ID!, $options: [ProjectV2SingleSelectFieldOptionInput!]) {
+		createProjectV2Field(input: {projectId: $projectId, name: "Milestone", dataType: SINGLE_SELECT, singleSelectOptions: $options}) {
+			projectV2Field {
+				... on ProjectV2SingleSelectField {
+					id
+					options {
+						id
+						name
+					}
+				}
+			}
+		}
+	}`
+	var queryResp struct {
+		Data struct {
+			CreateProjectV2Field struct {
+				ProjectV2Field *singleSelectField `json:"projectV2Field"`
+			} `json:"createProjectV2Field"`
+		} `json:"data"`
+	}
+
+	var options []map[string]interface{}
+	for _, m := range milestones {
+		options = append(options, map[string]interface{}{"name": m, "color": "GRAY", "description": ""})
+	}
+	qreq := &graphqlQuery{
+		Query:     queryString,
+		Variables: map[string]interface{}{"projectId": projectID, "options": options},
+	}
+	err := doGithubQuery(ctx, hc, qreq, &queryResp)
+	if err != nil {
+		return nil, err
+	}
+	return queryResp.Data.CreateProjectV2Field.ProjectV2Field, nil
+}
+
+func setProjectItemOption(ctx context.Context, hc *http.Client, projectID, itemID, fieldID, optionID string) error {
+	queryString := `mutation($projectId: ID!, $itemId: ID!, $fieldId: ID!, $optionId: String) {
+		updateProjectV2ItemFieldValue(input: {projectId: $projectId, itemId: $itemId, fieldId: $fieldId, value: { singleSelectOptionId: $optionId }}) {
+			clientMutationId
+		}
+	}`
+	qreq := &graphqlQuery{
+		Query:     queryString,
+		Variables: map[string]interface{}{"projectId": projectID, "itemId": itemID, "fieldId": fieldID, "optionId": optionID},
+	}
+	return doGithubQuery(ctx, hc, qreq, nil)
+}
+
+func updateProjectReadme(ctx context.Context, hc *http.Client, projectID, readme string) error {
+	queryString := `mutation($projectId: ID!, $readme: String) {
+		updateProjectV2(input: {projectId: $projectId, readme: $readme}) {
+			clientMutationId
+		}
+	}`
+	qreq := &graphqlQuery{
+		Query:     queryString,
+		Variables: map[string]interface{}{"projectId": projectID, "readme": readme},
+	}
+	return doGithubQuery(ctx, hc, qreq, nil)
+}
+
+func closeProject(ctx context.Context, hc *http.Client, projectID string) error {
+	queryString := `mutation($projectId: ID!) {
+		updateProjectV2(input: {projectId: $projectId, closed: true}) {
+			clientMutationId
+		}
+	}`
+	qreq := &graphqlQuery{
+		Query:     queryString,
+		Variables: map[string]interface{}{"projectId": projectID},
+	}
+	return doGithubQuery(ctx, hc, qreq, nil)
+}
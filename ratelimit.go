@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimiter spaces out the requests of concurrent workers so that together they stay
+// within a budget. A nil rateLimiter does not limit.
+type rateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// parseRateLimiter parses a number of requests per second. "" or 0 means no limit.
+func parseRateLimiter(s string) (*rateLimiter, error) {
+	if s == "" {
+		return nil, nil
+	}
+	rps, err := strconv.ParseFloat(s, 64)
+	if err != nil || rps < 0 {
+		return nil, fmt.Errorf("expected a number of requests per second: %q", s)
+	}
+	if rps == 0 {
+		return nil, nil
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / rps)}, nil
+}
+
+// wait blocks until the caller may make its request.
+func (rl *rateLimiter) wait(ctx context.Context) error {
+	if rl == nil {
+		return nil
+	}
+	rl.mu.Lock()
+	now := time.Now()
+	t := rl.next
+	if t.Before(now) {
+		t = now
+	}
+	rl.next = t.Add(rl.interval)
+	rl.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Until(t)):
+		return nil
+	}
+}
+
+// parseWorkers parses a number of workers defaulting to def.
+func parseWorkers(s string, def int) (int, error) {
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("expected a positive number of workers: %q", s)
+	}
+	return n, nil
+}
+
+// rateLimitedTransport waits for limiter before every request.
+type rateLimitedTransport struct {
+	limiter *rateLimiter
+	base    http.RoundTripper
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	err := t.limiter.wait(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
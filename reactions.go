@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v47/github"
+)
+
+type linearReaction struct {
+	Emoji string      `json:"emoji"`
+	User  *linearUser `json:"user"`
+}
+
+// githubReactions maps Linear emoji names and emoji to the eight reactions GitHub
+// supports.
+var githubReactions = map[string]string{
+	"+1":         "+1",
+	"thumbsup":   "+1",
+	"👍":          "+1",
+	"-1":         "-1",
+	"thumbsdown": "-1",
+	"👎":          "-1",
+	"laughing":   "laugh",
+	"smile":      "laugh",
+	"joy":        "laugh",
+	"😄":          "laugh",
+	"😆":          "laugh",
+	"😂":          "laugh",
+	"confused":   "confused",
+	"😕":          "confused",
+	"heart":      "heart",
+	"❤️":         "heart",
+	"❤":          "heart",
+	"tada":       "hooray",
+	"🎉":          "hooray",
+	"rocket":     "rocket",
+	"🚀":          "rocket",
+	"eyes":       "eyes",
+	"👀":          "eyes",
+}
+
+type githubReaction struct {
+	content string
+	// author is the GitHub login of the user who reacted if known.
+	author string
+}
+
+// fromLinearReactions returns the reactions GitHub supports.
+func fromLinearReactions(lrs []*linearReaction) []*githubReaction {
+	var grs []*githubReaction
+	for _, lr := range lrs {
+		content, ok := githubReactions[lr.Emoji]
+		if !ok {
+			continue
+		}
+		gr := &githubReaction{content: content}
+		if lr.User != nil {
+			gr.author = emailsToGithubMap[lr.User.Email]
+		}
+		grs = append(grs, gr)
+	}
+	return grs
+}
+
+// formatReactions returns the count of each emoji for the field table as GitHub can only
+// show one reaction of each type per user and doesn't support most emoji.
+func formatReactions(lrs []*linearReaction) string {
+	counts := make(map[string]int)
+	for _, lr := range lrs {
+		counts[lr.Emoji]++
+	}
+	var emojis []string
+	for e := range counts {
+		emojis = append(emojis, e)
+	}
+	sort.Slice(emojis, func(i, j int) bool {
+		if counts[emojis[i]] != counts[emojis[j]] {
+			return counts[emojis[i]] > counts[emojis[j]]
+		}
+		return emojis[i] < emojis[j]
+	})
+	var a []string
+	for _, e := range emojis {
+		a = append(a, fmt.Sprintf("%s %d", formatEmoji(e), counts[e]))
+	}
+	return strings.Join(a, ", ")
+}
+
+func formatEmoji(e string) string {
+	for _, r := range e {
+		if r > 127 {
+			return e
+		}
+	}
+	return ":" + e + ":"
+}
+
+// createReactions creates each reaction with the client of the user who reacted if there
+// is one. The remaining reactions are created once per type with gc.
+func createReactions(ctx context.Context, gc *github.Client, ident string, grs []*githubReaction, create func(*github.Client, string) error) error {
+	created := make(map[string]bool)
+	for _, gr := range grs {
+		rgc := githubUserClient(gr.author)
+		if rgc == nil {
+			if created[gr.content] {
+				continue
+			}
+			created[gr.content] = true
+			rgc = gc
+		}
+		log.Printf("%s: creating reaction %s", ident, gr.content)
+		err := create(rgc, gr.content)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func createIssueReactions(ctx context.Context, gc *github.Client, ident string, number int, grs []*githubReaction) error {
+	return createReactions(ctx, gc, ident, grs, func(rgc *github.Client, content string) error {
+		_, _, err := rgc.Reactions.CreateIssueReaction(ctx, orgName, repoName, number, content)
+		return err
+	})
+}
+
+func createCommentReactions(ctx context.Context, gc *github.Client, ident string, commentID int64, grs []*githubReaction) error {
+	return createReactions(ctx, gc, ident, grs, func(rgc *github.Client, content string) error {
+		_, _, err := rgc.Reactions.CreateIssueCommentReaction(ctx, orgName, repoName, commentID, content)
+		return err
+	})
+}
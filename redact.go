@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// redactConfig is read from the JSON file at $BYELINEAR_REDACT.
+type redactConfig struct {
+	// Detectors enables built in rules by name. See redactDetectors.
+	Detectors []string      `json:"detectors"`
+	Rules     []*redactRule `json:"rules"`
+}
+
+type redactRule struct {
+	Name string `json:"name"`
+	// Regex and Keywords are the text to match. Keywords match case insensitively.
+	Regex    string   `json:"regex"`
+	Keywords []string `json:"keywords"`
+	// Action is either rewrite or block. Defaults to rewrite.
+	Action string `json:"action"`
+	// Replace replaces matches when rewriting. Defaults to [redacted].
+	Replace string `json:"replace"`
+
+	re *regexp.Regexp
+}
+
+const (
+	redactRewrite = "rewrite"
+	redactBlock   = "block"
+)
+
+var redactDetectors = map[string][]*redactRule{
+	"secrets": {
+		{Name: "github token", Regex: `\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`},
+		{Name: "linear api key", Regex: `\blin_(api|oauth)_[A-Za-z0-9]{32,}\b`},
+		{Name: "slack token", Regex: `\bxox[abposr]-[A-Za-z0-9-]{10,}\b`},
+		{Name: "aws access key", Regex: `\b(AKIA|ASIA)[0-9A-Z]{16}\b`},
+		{Name: "stripe key", Regex: `\b[sr]k_(live|test)_[A-Za-z0-9]{16,}\b`},
+		{Name: "google api key", Regex: `\bAIza[0-9A-Za-z_-]{35}\b`},
+		{Name: "private key", Regex: `-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`},
+	},
+	"jwts": {
+		{Name: "jwt", Regex: `\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\b`},
+	},
+	"emails": {
+		{Name: "email", Regex: `\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`, Replace: "[email]"},
+	},
+}
+
+// redactor is nil unless $BYELINEAR_REDACT is set.
+var redactor *redactConfig
+
+func loadRedactor() error {
+	if byelinearRedact == "" {
+		return nil
+	}
+	b, err := os.ReadFile(byelinearRedact)
+	if err != nil {
+		return fmt.Errorf("$BYELINEAR_REDACT: %w", err)
+	}
+	var rc *redactConfig
+	err = json.Unmarshal(b, &rc)
+	if err != nil {
+		return fmt.Errorf("$BYELINEAR_REDACT: %w", err)
+	}
+
+	rules := rc.Rules
+	for _, d := range rc.Detectors {
+		drules, ok := redactDetectors[d]
+		if !ok {
+			return fmt.Errorf("$BYELINEAR_REDACT: unknown detector %q", d)
+		}
+		rules = append(rules, drules...)
+	}
+	for _, r := range rules {
+		err = r.compile()
+		if err != nil {
+			return fmt.Errorf("$BYELINEAR_REDACT: rule %q: %w", r.Name, err)
+		}
+	}
+	rc.Rules = rules
+	redactor = rc
+	return nil
+}
+
+func (r *redactRule) compile() error {
+	switch r.Action {
+	case "":
+		r.Action = redactRewrite
+	case redactRewrite, redactBlock:
+	default:
+		return fmt.Errorf("action must be rewrite or block: %q", r.Action)
+	}
+	if r.Replace == "" {
+		r.Replace = "[redacted]"
+	}
+
+	var patterns []string
+	if r.Regex != "" {
+		patterns = append(patterns, r.Regex)
+	}
+	for _, k := range r.Keywords {
+		patterns = append(patterns, "(?i)"+regexp.QuoteMeta(k))
+	}
+	if len(patterns) == 0 {
+		return fmt.Errorf("regex or keywords required")
+	}
+	var err error
+	r.re, err = regexp.Compile(strings.Join(patterns, "|"))
+	return err
+}
+
+type redaction struct {
+	Field  string `json:"field"`
+	Rule   string `json:"rule"`
+	Action string `json:"action"`
+	// Match is shortened so that the report doesn't contain the redacted secrets.
+	Match string `json:"match"`
+}
+
+// redact rewrites every field of iss that will be sent to GitHub and returns what was
+// redacted. blocked is set if any match was of a rule with the block action in which case
+// iss must not be exported.
+func (rc *redactConfig) redact(iss *githubIssue) (rs []*redaction, blocked bool) {
+	field := func(name string, s *string) {
+		frs, fblocked := rc.redactString(name, s)
+		rs = append(rs, frs...)
+		blocked = blocked || fblocked
+	}
+
+	field("title", &iss.title)
+	field("body", &iss.body)
+	for i, c := range iss.comments {
+		field(fmt.Sprintf("comment %d", i), &c.text)
+	}
+	field("closed by", &iss.closedBy)
+	field("timeline", &iss.timeline)
+	if iss.project != nil {
+		field("project description", &iss.project.desc)
+		field("project readme", &iss.project.readme)
+	}
+	return rs, blocked
+}
+
+// redactString rewrites s with every rule and returns what was redacted as the field
+// name.
+func (rc *redactConfig) redactString(name string, s *string) (rs []*redaction, blocked bool) {
+	for _, r := range rc.Rules {
+		*s = r.re.ReplaceAllStringFunc(*s, func(m string) string {
+			rs = append(rs, &redaction{
+				Field:  name,
+				Rule:   r.Name,
+				Action: r.Action,
+				Match:  maskMatch(m),
+			})
+			if r.Action == redactBlock {
+				blocked = true
+				return m
+			}
+			return r.Replace
+		})
+	}
+	return rs, blocked
+}
+
+func maskMatch(m string) string {
+	r := []rune(m)
+	if len(r) <= 4 {
+		return strings.Repeat("*", len(r))
+	}
+	return string(r[:4]) + strings.Repeat("*", len(r)-4)
+}
+
+// redactionReport maps issue identifiers to their redactions. It is written to
+// redactions.json in the corpus for review.
+type redactionReport map[string][]*redaction
+
+func readRedactionReport() (redactionReport, error) {
+	b, err := os.ReadFile(filepath.Join(byelinearCorpus, "redactions.json"))
+	if os.IsNotExist(err) {
+		return redactionReport{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rr redactionReport
+	err = json.Unmarshal(b, &rr)
+	if err != nil {
+		return nil, err
+	}
+	if rr == nil {
+		rr = redactionReport{}
+	}
+	return rr, nil
+}
+
+func (rr redactionReport) write() error {
+	b, err := json.MarshalIndent(rr, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(byelinearCorpus, "redactions.json"), b)
+}
+
+// redactIssue redacts iss and records the redactions in the report. It returns false if
+// iss is blocked.
+func (rr redactionReport) redactIssue(ident string, iss *githubIssue) (bool, error) {
+	if redactor == nil {
+		return true, nil
+	}
+	rs, blocked := redactor.redact(iss)
+	if len(rs) == 0 {
+		if _, ok := rr[ident]; !ok {
+			return true, nil
+		}
+		delete(rr, ident)
+	} else {
+		rr[ident] = rs
+	}
+	return !blocked, rr.write()
+}
+
+// redactReport writes the redaction report for every issue matching the filter without
+// exporting anything so that it can be reviewed before running to-github.
+func (s *state) redactReport() error {
+	if redactor == nil {
+		return fmt.Errorf("$BYELINEAR_REDACT is required")
+	}
+	err := readLinearProjects()
+	if err != nil {
+		return err
+	}
+
+	rr := redactionReport{}
+	counts := map[string]int{}
+	for _, iss := range s.Issues {
+		if !filter.matchIdentifier(iss.Identifier) {
+			continue
+		}
+		liss, err := iss.linear()
+		if err != nil {
+			return err
+		}
+		if liss.Creator == nil || !filter.match(liss) {
+			continue
+		}
+		giss, err := fromLinearIssue(liss, withAuthor(liss))
+		if err != nil {
+			return err
+		}
+		rs, blocked := redactor.redact(giss)
+		if len(rs) == 0 {
+			continue
+		}
+		rr[iss.Identifier] = rs
+		for _, r := range rs {
+			counts[r.Rule]++
+		}
+		if blocked {
+			counts["blocked issues"]++
+		}
+	}
+
+	var names []string
+	for n := range counts {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		fmt.Printf("%s: %d\n", n, counts[n])
+	}
+	fmt.Printf("%d issues with redactions written to %s\n", len(rr), filepath.Join(byelinearCorpus, "redactions.json"))
+	return rr.write()
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// githubRef is a GitHub pull request or commit attached to a Linear issue.
+type githubRef struct {
+	owner  string
+	repo   string
+	number int
+	sha    string
+	merged bool
+}
+
+// githubRef parses the attachment's URL as a GitHub pull request or commit.
+func (la *linearAttachment) githubRef() (*githubRef, bool) {
+	u, err := url.Parse(la.URL)
+	if err != nil || u.Host != "github.com" {
+		return nil, false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 4 {
+		return nil, false
+	}
+	ref := &githubRef{
+		owner: parts[0],
+		repo:  parts[1],
+	}
+	switch parts[2] {
+	case "pull":
+		ref.number, err = strconv.Atoi(parts[3])
+		if err != nil {
+			return nil, false
+		}
+		status, _ := la.Metadata["status"].(string)
+		merged, _ := la.Metadata["merged"].(bool)
+		ref.merged = status == "merged" || merged
+	case "commit":
+		ref.sha = parts[3]
+	default:
+		return nil, false
+	}
+	return ref, true
+}
+
+// String returns the reference in the form GitHub autolinks. The repository is left out
+// for references into the repository being exported to.
+func (r *githubRef) String() string {
+	var repo string
+	if !strings.EqualFold(r.owner, orgName) || !strings.EqualFold(r.repo, repoName) {
+		repo = r.owner + "/" + r.repo
+	}
+	if r.sha != "" {
+		sha := r.sha
+		if len(sha) > 7 {
+			sha = sha[:7]
+		}
+		if repo == "" {
+			return sha
+		}
+		return repo + "@" + sha
+	}
+	return fmt.Sprintf("%s#%d", repo, r.number)
+}
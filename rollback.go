@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+)
+
+const rollbackUsage = `usage: byelinear rollback [ -dry-run ] [ -delete ]`
+
+// rollback removes the GitHub objects recorded in the journal by to-github. Issues are
+// closed as not planned and locked unless del is set in which case they are deleted.
+// Created labels and projects are deleted. The state is updated so that the rolled back
+// issues are exported again by the next to-github.
+func (s *state) rollback(ctx context.Context, args []string) error {
+	var dryRun, del bool
+	for _, arg := range args {
+		switch arg {
+		case "-dry-run":
+			dryRun = true
+		case "-delete":
+			del = true
+		default:
+			return errors.New(rollbackUsage)
+		}
+	}
+
+	entries, err := readJournal()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		log.Printf("nothing to roll back: %s is empty or missing", journalPath())
+		return nil
+	}
+
+	var issues, labels, projects []*journalEntry
+	// Newest first so that issues are removed in the reverse order of their creation.
+	for i := len(entries) - 1; i >= 0; i-- {
+		switch e := entries[i]; e.Kind {
+		case journalIssue:
+			issues = append(issues, e)
+		case journalLabel:
+			labels = append(labels, e)
+		case journalProject:
+			projects = append(projects, e)
+		}
+	}
+
+	issueAction := "close and lock"
+	if del {
+		issueAction = "delete"
+	}
+	for _, e := range issues {
+		fmt.Printf("%s issue %s#%d (%s)\n", issueAction, e.Repo, e.Number, e.Ident)
+	}
+	for _, e := range labels {
+		fmt.Printf("delete label %s %q\n", e.Repo, e.Name)
+	}
+	for _, e := range projects {
+		fmt.Printf("delete project %q\n", e.Name)
+	}
+	if dryRun {
+		return nil
+	}
+	question := fmt.Sprintf("%s %d issues and delete %d labels and %d projects?", issueAction, len(issues), len(labels), len(projects))
+	if !confirm(question) {
+		return errors.New("rollback aborted")
+	}
+
+	gc, err := githubClientFromEnv(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range issues {
+		owner, repo, ok := strings.Cut(e.Repo, "/")
+		if !ok {
+			return fmt.Errorf("invalid journal entry: repo %q", e.Repo)
+		}
+		log.Printf("%s: rolling back %s#%d", e.Ident, e.Repo, e.Number)
+		if del {
+			err = deleteGithubIssue(ctx, gc, owner, repo, e.Number)
+		} else {
+			err = closeAndLockGithubIssue(ctx, gc, owner, repo, e.Number)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", e.Ident, err)
+		}
+
+		iss, ok := s.issue(e.Ident)
+		if ok && e.Repo == orgName+"/"+repoName && iss.GithubNumber == e.Number {
+			iss.ExportedToGithub = false
+			iss.GithubNumber = 0
+			iss.GithubStep = 0
+			iss.GithubImportID = 0
+			err = writeState(s)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, e := range labels {
+		owner, repo, ok := strings.Cut(e.Repo, "/")
+		if !ok {
+			return fmt.Errorf("invalid journal entry: repo %q", e.Repo)
+		}
+		log.Printf("deleting label %s %q", e.Repo, e.Name)
+		_, err = gc.Issues.DeleteLabel(ctx, owner, repo, e.Name)
+		if err != nil && !isNotFoundErr(err) {
+			return err
+		}
+		s.removeLabel(e.Name)
+		err = writeState(s)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, e := range projects {
+		log.Printf("deleting project %q", e.Name)
+		err = deleteProject(ctx, gc.Client(), e.NodeID)
+		if err != nil {
+			return err
+		}
+		s.removeProject(e.Name)
+		err = writeState(s)
+		if err != nil {
+			return err
+		}
+	}
+
+	rolledBack := fmt.Sprintf("%s.%d", journalPath(), time.Now().Unix())
+	log.Printf("rolled back: moving %s to %s", journalPath(), rolledBack)
+	return os.Rename(journalPath(), rolledBack)
+}
+
+func closeAndLockGithubIssue(ctx context.Context, gc *github.Client, owner, repo string, number int) error {
+	_, _, err := gc.Issues.Edit(ctx, owner, repo, number, &github.IssueRequest{
+		State:       github.String("closed"),
+		StateReason: github.String("not_planned"),
+	})
+	if isNotFoundErr(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = gc.Issues.Lock(ctx, owner, repo, number, &github.LockIssueOptions{
+		LockReason: "resolved",
+	})
+	return err
+}
+
+// deleteGithubIssue requires the token to have admin access to the repository.
+func deleteGithubIssue(ctx context.Context, gc *github.Client, owner, repo string, number int) error {
+	giss, _, err := gc.Issues.Get(ctx, owner, repo, number)
+	if isNotFoundErr(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	queryString := `mutation($issueId: ID!) {
+		deleteIssue(input: {issueId: $issueId}) {
+			clientMutationId
+		}
+	}`
+	qreq := &graphqlQuery{
+		Query:     queryString,
+		Variables: map[string]interface{}{"issueId": giss.GetNodeID()},
+	}
+	return doGithubQuery(ctx, gc.Client(), qreq, nil)
+}
+
+func deleteProject(ctx context.Context, hc *http.Client, projectID string) error {
+	queryString := `mutation($projectId: ID!) {
+		deleteProjectV2(input: {projectId: $projectId}) {
+			clientMutationId
+		}
+	}`
+	qreq := &graphqlQuery{
+		Query:     queryString,
+		Variables: map[string]interface{}{"projectId": projectID},
+	}
+	return doGithubQuery(ctx, hc, qreq, nil)
+}
+
+func isNotFoundErr(err error) bool {
+	var ghErr *github.ErrorResponse
+	return errors.As(err, &ghErr) && ghErr.Response != nil && ghErr.Response.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// corpusSchemaVersion is the version of state.json and the issue files written by this
+// build. Bump it and register a migration in corpusMigrations whenever a change to state
+// or linearIssue would break older corpora.
+//
+// Corpora written before versioning have no schema_version and are version 1.
+const corpusSchemaVersion = 2
+
+// corpusMigration upgrades the decoded JSON of state.json and an issue file from the
+// previous version. Either function may be nil.
+type corpusMigration struct {
+	state func(s map[string]interface{}) error
+	issue func(liss map[string]interface{}) error
+}
+
+// corpusMigrations maps each version to the migration from the version before it.
+var corpusMigrations = map[int]*corpusMigration{
+	2: {
+		// The project ID was tagged keyName. from-linear resumed after the last issue
+		// instead of linear_cursor.
+		state: func(s map[string]interface{}) error {
+			issues, _ := s["issues"].([]interface{})
+			if cursor, _ := s["linear_cursor"].(string); cursor == "" && len(issues) > 0 {
+				if last, ok := issues[len(issues)-1].(map[string]interface{}); ok {
+					s["linear_cursor"] = last["identifier"]
+				}
+			}
+			projects, _ := s["projects"].([]interface{})
+			for _, p := range projects {
+				p, ok := p.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if id, ok := p["keyName"]; ok {
+					p["id"] = id
+					delete(p, "keyName")
+				}
+			}
+			return nil
+		},
+	},
+}
+
+// migrateCorpusJSON upgrades b from its schema_version to corpusSchemaVersion with
+// migrate. It returns b as is if it is already at corpusSchemaVersion.
+func migrateCorpusJSON(name string, b []byte, migrate func(m *corpusMigration) func(map[string]interface{}) error) ([]byte, bool, error) {
+	var v map[string]interface{}
+	err := json.Unmarshal(b, &v)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", name, err)
+	}
+
+	version := 1
+	if n, ok := v["schema_version"].(float64); ok {
+		version = int(n)
+	}
+	if version > corpusSchemaVersion {
+		return nil, false, fmt.Errorf("%s: corpus schema version %d is newer than the version %d supported by this build of byelinear: upgrade byelinear", name, version, corpusSchemaVersion)
+	}
+	if version == corpusSchemaVersion {
+		return b, false, nil
+	}
+
+	for version < corpusSchemaVersion {
+		version++
+		if m, ok := corpusMigrations[version]; ok {
+			if fn := migrate(m); fn != nil {
+				err = fn(v)
+				if err != nil {
+					return nil, false, fmt.Errorf("%s: failed to migrate to schema version %d: %w", name, version, err)
+				}
+			}
+		}
+	}
+	v["schema_version"] = corpusSchemaVersion
+	b, err = json.Marshal(v)
+	if err != nil {
+		return nil, false, err
+	}
+	return b, true, nil
+}
+
+// migrateState upgrades state.json and every issue file in a JSON corpus in place.
+func migrateState(sb []byte) ([]byte, error) {
+	sb, migrated, err := migrateCorpusJSON("state.json", sb, func(m *corpusMigration) func(map[string]interface{}) error {
+		return m.state
+	})
+	if err != nil || !migrated {
+		return sb, err
+	}
+	log.Printf("migrating corpus to schema version %d", corpusSchemaVersion)
+
+	var s *state
+	err = json.Unmarshal(sb, &s)
+	if err != nil {
+		return nil, err
+	}
+	for _, iss := range s.Issues {
+		_, err = jsonCorpus{}.readIssue(iss.Identifier)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	err = writeFile(filepath.Join(byelinearCorpus, "state.json"), sb)
+	if err != nil {
+		return nil, err
+	}
+	return sb, nil
+}
+
+// migrateIssueJSON upgrades the issue JSON of ident to corpusSchemaVersion.
+func migrateIssueJSON(ident string, b []byte) ([]byte, bool, error) {
+	return migrateCorpusJSON(ident+".json", b, func(m *corpusMigration) func(map[string]interface{}) error {
+		return m.issue
+	})
+}
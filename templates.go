@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Templates of the GitHub issue title, body and comments. Each is overridden by the file
+// of the same name in $BYELINEAR_TEMPLATES.
+var (
+	titleTemplate   = template.Must(newTemplate("title.tmpl", defaultTitleTemplate))
+	bodyTemplate    = template.Must(newTemplate("body.tmpl", defaultBodyTemplate))
+	commentTemplate = template.Must(newTemplate("comment.tmpl", defaultCommentTemplate))
+)
+
+const defaultTitleTemplate = `{{.Issue.Identifier}}: {{.Issue.Title}}`
+
+const defaultBodyTemplate = `field | value
+| - | - |
+url | {{.Issue.URL}}
+{{if .WithAuthor}}author | @{{.Author}}
+{{end}}date | {{formatTime .Issue.CreatedAt}}
+state | {{.Issue.State.Name}}
+project | {{.Issue.Project.Name}}
+priority | {{.Issue.PriorityLabel}}
+assignee | {{if .Issue.Assignee}}@{{.Assignee}}{{end}}
+due | {{.Issue.DueDate}}
+labels | {{join .Labels " "}}
+related | {{join .Related " "}}
+parent | {{.Parent}}
+children | {{join .Children " "}}
+PRs | {{join .PRs " "}}
+reactions | {{.Reactions}}
+{{with .Issue.Description}}
+{{.}}{{end}}{{with .Attachments}}
+
+{{.}}{{end}}{{with .CustomerNeeds}}
+
+{{.}}{{end}}`
+
+const defaultCommentTemplate = `field | value
+|-|-|
+url | {{.URL}}
+{{if .WithAuthor}}author | @{{.Author}}
+{{end}}date | {{formatTime .CreatedAt}}
+{{with .EditedAt}}edited | {{formatTime .}}
+{{end}}{{with .Reactions}}reactions | {{.}}
+{{end}}
+{{with .ReplyTo}}> In reply to [@{{.Author}}]({{.URL}}):
+> {{.Snippet}}
+
+{{end}}{{.Body}}`
+
+var templateFuncs = template.FuncMap{
+	"formatTime": formatTime,
+	"join":       strings.Join,
+	"github":     func(email string) string { return emailsToGithubMap[email] },
+	"snippet":    quoteSnippet,
+}
+
+func newTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Parse(text)
+}
+
+// loadTemplates replaces the default templates with those in $BYELINEAR_TEMPLATES.
+func loadTemplates() error {
+	if byelinearTemplates == "" {
+		return nil
+	}
+	for _, t := range []**template.Template{&titleTemplate, &bodyTemplate, &commentTemplate} {
+		name := (*t).Name()
+		b, err := os.ReadFile(filepath.Join(byelinearTemplates, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("$BYELINEAR_TEMPLATES: %w", err)
+		}
+		*t, err = newTemplate(name, string(b))
+		if err != nil {
+			return fmt.Errorf("$BYELINEAR_TEMPLATES: %w", err)
+		}
+	}
+	return nil
+}
+
+// issueTemplateData is the data of the title and body templates.
+type issueTemplateData struct {
+	// Issue is the issue as fetched from Linear.
+	Issue *linearIssue
+	// Author and Assignee are the GitHub logins of the creator and assignee. WithAuthor
+	// is false when the issue is created by its creator.
+	Author     string
+	WithAuthor bool
+	Assignee   string
+	// Labels are the names of the labels. Related, Parent and Children are identifiers.
+	Labels   []string
+	Related  []string
+	Parent   string
+	Children []string
+	// PRs are references to the attached pull requests and commits such as org/repo#1.
+	PRs []string
+	// Reactions summarizes the count of each reaction.
+	Reactions string
+	// Attachments and CustomerNeeds are the formatted Markdown sections or empty.
+	Attachments   string
+	CustomerNeeds string
+}
+
+// commentTemplateData is the data of the comment template.
+type commentTemplateData struct {
+	// Comment is the comment as fetched from Linear.
+	Comment *linearComment
+	URL     string
+	// Author is the GitHub login of the author. WithAuthor is false when the comment is
+	// created by the author.
+	Author     string
+	WithAuthor bool
+	CreatedAt  time.Time
+	EditedAt   *time.Time
+	Reactions  string
+	// Body is the comment body after redaction.
+	Body string
+	// ReplyTo is nil unless the comment is a reply.
+	ReplyTo *replyTemplateData
+}
+
+type replyTemplateData struct {
+	Author string
+	// URL is the parent comment on GitHub if it was created and otherwise on Linear.
+	URL     string
+	Snippet string
+}
+
+func newIssueTemplateData(liss *linearIssue, withAuthor bool) *issueTemplateData {
+	d := &issueTemplateData{
+		Issue:         liss,
+		Author:        emailsToGithubMap[liss.Creator.Email],
+		WithAuthor:    withAuthor,
+		Labels:        liss.labelsArr(),
+		Related:       liss.relationsArr(),
+		Parent:        liss.Parent.Identifier,
+		Children:      liss.childrenArr(),
+		PRs:           liss.prs(),
+		Reactions:     formatReactions(liss.Reactions),
+		Attachments:   formatAttachments(liss),
+		CustomerNeeds: formatCustomerNeeds(liss),
+	}
+	if liss.Assignee != nil {
+		d.Assignee = emailsToGithubMap[liss.Assignee.Email]
+	}
+	return d
+}
+
+func executeTemplate(t *template.Template, data interface{}) (string, error) {
+	var b bytes.Buffer
+	err := t.Execute(&b, data)
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/google/go-github/v47/github"
+	"golang.org/x/oauth2"
+)
+
+// githubUserClients holds a client per GitHub login in $BYELINEAR_USER_TOKENS. Issues
+// and comments by these users are created with their own client so that GitHub shows
+// them as the author.
+var githubUserClients map[string]*github.Client
+
+// loadGithubUserClients reads the JSON object of GitHub logins to tokens at
+// $BYELINEAR_USER_TOKENS.
+func loadGithubUserClients(ctx context.Context) error {
+	if byelinearUserTokens == "" {
+		return nil
+	}
+	b, err := os.ReadFile(byelinearUserTokens)
+	if err != nil {
+		return fmt.Errorf("$BYELINEAR_USER_TOKENS: %w", err)
+	}
+	var tokens map[string]string
+	err = json.Unmarshal(b, &tokens)
+	if err != nil {
+		return fmt.Errorf("$BYELINEAR_USER_TOKENS: %w", err)
+	}
+
+	githubUserClients = make(map[string]*github.Client, len(tokens))
+	for login, token := range tokens {
+		gc, err := newGithubClient(oauth2.NewClient(ctx, oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: token},
+		)))
+		if err != nil {
+			return err
+		}
+		githubUserClients[login] = gc
+	}
+	return nil
+}
+
+// githubUserClient returns the client for login or nil if there is no token for login.
+func githubUserClient(login string) *github.Client {
+	if login == "" {
+		return nil
+	}
+	return githubUserClients[login]
+}
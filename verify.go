@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+)
+
+// linearConnectionLimits are the page sizes of the connections queryLinearIssues does
+// not paginate. A connection with this many nodes may have been truncated.
+var linearConnectionLimits = map[string]int{
+	"labels":      10,
+	"relations":   10,
+	"children":    10,
+	"attachments": 50,
+	"needs":       50,
+}
+
+// githubLabelColor matches the label colors GitHub accepts once the # is trimmed.
+var githubLabelColor = regexp.MustCompile(`^#?[0-9a-fA-F]{6}$`)
+
+// verify checks the consistency of the corpus and prints every problem found. It returns
+// an error if there are any so that it can gate a migration.
+func (s *state) verify() error {
+	var problems int
+	report := func(format string, v ...interface{}) {
+		problems++
+		fmt.Printf(format+"\n", v...)
+	}
+
+	idents, err := corpus.issueIdents()
+	if err != nil {
+		return err
+	}
+	stored := make(map[string]bool, len(idents))
+	for _, ident := range idents {
+		stored[ident] = true
+		if _, ok := s.issue(ident); !ok {
+			report("%s: orphan issue file: not in the state", ident)
+		}
+	}
+
+	unmapped := map[string]int{}
+	checkUser := func(lu *linearUser) {
+		if lu != nil && lu.Email != "" && emailsToGithubMap[lu.Email] == "" {
+			unmapped[lu.Email]++
+		}
+	}
+	badColors := map[string]string{}
+
+	for _, iss := range s.Issues {
+		if !stored[iss.Identifier] {
+			report("%s: missing issue file", iss.Identifier)
+			continue
+		}
+		b, err := corpus.readIssue(iss.Identifier)
+		if err != nil {
+			report("%s: unreadable issue file: %v", iss.Identifier, err)
+			continue
+		}
+		var liss *linearIssue
+		err = json.Unmarshal(b, &liss)
+		if err != nil {
+			report("%s: corrupt issue file: %v", iss.Identifier, err)
+			continue
+		}
+		if liss.Identifier != iss.Identifier {
+			report("%s: issue file is for %s", iss.Identifier, liss.Identifier)
+		}
+
+		for _, ident := range liss.relationsArr() {
+			if _, ok := s.issue(ident); !ok {
+				report("%s: related issue %s is not in the corpus", iss.Identifier, ident)
+			}
+		}
+		if ident := liss.Parent.Identifier; ident != "" {
+			if _, ok := s.issue(ident); !ok {
+				report("%s: parent issue %s is not in the corpus", iss.Identifier, ident)
+			}
+		}
+		for _, ident := range liss.childrenArr() {
+			if _, ok := s.issue(ident); !ok {
+				report("%s: child issue %s is not in the corpus", iss.Identifier, ident)
+			}
+		}
+
+		if liss.Comments.PageInfo.HasNextPage {
+			report("%s: comments truncated at %d: run from-linear again", iss.Identifier, len(liss.Comments.Nodes))
+		}
+		// A slice keeps the report in the same order on every run.
+		counts := []struct {
+			conn string
+			n    int
+		}{
+			{"labels", len(liss.Labels.Nodes)},
+			{"relations", len(liss.Relations.Nodes)},
+			{"children", len(liss.Children.Nodes)},
+			{"attachments", len(liss.Attachments.Nodes)},
+			{"needs", len(liss.Needs.Nodes)},
+		}
+		for _, c := range counts {
+			if c.n >= linearConnectionLimits[c.conn] {
+				report("%s: %s may be truncated at %d", iss.Identifier, c.conn, c.n)
+			}
+		}
+
+		checkUser(liss.Creator)
+		checkUser(liss.Assignee)
+		for _, r := range liss.Reactions {
+			checkUser(r.User)
+		}
+		for _, c := range liss.Comments.Nodes {
+			checkUser(c.User)
+			for _, r := range c.Reactions {
+				checkUser(r.User)
+			}
+		}
+		for _, h := range liss.History {
+			checkUser(h.Actor)
+		}
+
+		for _, l := range liss.Labels.Nodes {
+			if !githubLabelColor.MatchString(l.Color) {
+				badColors[l.Name] = l.Color
+			}
+		}
+	}
+
+	var emails []string
+	for email := range unmapped {
+		emails = append(emails, email)
+	}
+	sort.Strings(emails)
+	for _, email := range emails {
+		report("%s: no github user mapped in %d places", email, unmapped[email])
+	}
+
+	var labels []string
+	for l := range badColors {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+	for _, l := range labels {
+		report("label %q: color %q is not a valid github color", l, badColors[l])
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("%d problems found in %s", problems, byelinearCorpus)
+	}
+	log.Printf("%s: %d issues ok", byelinearCorpus, len(s.Issues))
+	return nil
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v47/github"
+)
+
+// githubIssueSnapshot is the state of an exported issue on GitHub.
+type githubIssueSnapshot struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	State       string `json:"state"`
+	StateReason string `json:"stateReason"`
+	Labels      struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+	Assignees struct {
+		Nodes []struct {
+			Login string `json:"login"`
+		} `json:"nodes"`
+	} `json:"assignees"`
+	Comments struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"comments"`
+	ProjectItems struct {
+		Nodes []struct {
+			Project struct {
+				Title string `json:"title"`
+			} `json:"project"`
+			Status *struct {
+				Name string `json:"name"`
+			} `json:"status"`
+		} `json:"nodes"`
+	} `json:"projectItems"`
+}
+
+func queryGithubIssue(ctx context.Context, hc *http.Client, number int) (*githubIssueSnapshot, error) {
+	queryString := `query($owner: String!, $repo: String!, $number: Int!) {
+		repository(owner: $owner, name: $repo) {
+			issue(number: $number) {
+				id
+				title
+				state
+				stateReason
+				labels(first: 100) {
+					nodes {
+						name
+					}
+				}
+				assignees(first: 10) {
+					nodes {
+						login
+					}
+				}
+				comments {
+					totalCount
+				}
+				projectItems(first: 10) {
+					nodes {
+						project {
+							title
+						}
+						status: fieldValueByName(name: "Status") {
+							... on ProjectV2ItemFieldSingleSelectValue {
+								name
+							}
+						}
+					}
+				}
+			}
+		}
+	}`
+	var queryResp struct {
+		Data struct {
+			Repository struct {
+				Issue *githubIssueSnapshot `json:"issue"`
+			} `json:"repository"`
+		} `json:"data"`
+	}
+
+	qreq := &graphqlQuery{
+		Query:     queryString,
+		Variables: map[string]interface{}{"owner": orgName, "repo": repoName, "number": number},
+	}
+	err := doGithubQuery(ctx, hc, qreq, &queryResp)
+	if err != nil {
+		return nil, err
+	}
+	if queryResp.Data.Repository.Issue == nil {
+		return nil, fmt.Errorf("issue #%d not found in %s/%s", number, orgName, repoName)
+	}
+	return queryResp.Data.Repository.Issue, nil
+}
+
+// githubDiscrepancy is a difference between an exported issue and what to-github would
+// create from the corpus now.
+type githubDiscrepancy struct {
+	field string
+	want  string
+	got   string
+	// repairable is false for differences verify-github cannot fix such as missing
+	// comments.
+	repairable bool
+}
+
+// compare returns the differences between the issue on GitHub and iss.
+func (snap *githubIssueSnapshot) compare(iss *githubIssue) []*githubDiscrepancy {
+	var ds []*githubDiscrepancy
+	diff := func(field, want, got string, repairable bool) {
+		if want != got {
+			ds = append(ds, &githubDiscrepancy{field: field, want: want, got: got, repairable: repairable})
+		}
+	}
+
+	diff("title", iss.title, snap.Title, true)
+
+	var want, got []string
+	for _, l := range iss.labels {
+		want = append(want, l.name)
+	}
+	for _, l := range snap.Labels.Nodes {
+		got = append(got, l.Name)
+	}
+	diff("labels", formatSet(want), formatSet(got), true)
+
+	var assignees []string
+	for _, a := range snap.Assignees.Nodes {
+		assignees = append(assignees, a.Login)
+	}
+	diff("assignee", iss.assignee, strings.Join(assignees, ", "), true)
+
+	wantState := "open"
+	switch iss.state {
+	case "Done":
+		wantState = "closed completed"
+	case "Canceled":
+		wantState = "closed not_planned"
+	}
+	gotState := "open"
+	if snap.State == "CLOSED" {
+		gotState = "closed " + strings.ToLower(snap.StateReason)
+	}
+	diff("state", wantState, gotState, true)
+
+	comments := len(iss.comments)
+	if iss.closedBy != "" {
+		comments++
+	}
+	if iss.timeline != "" {
+		comments++
+	}
+	diff("comments", fmt.Sprint(comments), fmt.Sprint(snap.Comments.TotalCount), false)
+
+	if iss.project != nil {
+		var status string
+		var found bool
+		for _, item := range snap.ProjectItems.Nodes {
+			if item.Project.Title != iss.project.name {
+				continue
+			}
+			found = true
+			if item.Status != nil {
+				status = item.Status.Name
+			}
+		}
+		if !found {
+			diff("project", iss.project.name, "", true)
+		} else if want := githubStatus(iss.state); want != "" {
+			diff("status", want, status, true)
+		}
+	}
+	return ds
+}
+
+// formatSet returns the sorted, comma separated elements of a.
+func formatSet(a []string) string {
+	a = append([]string(nil), a...)
+	sort.Strings(a)
+	return strings.Join(a, ", ")
+}
+
+// verifyGithub compares every exported issue matching the filter to the issue to-github
+// would create from the corpus now and offers to repair the differences.
+func (s *state) verifyGithub(ctx context.Context) error {
+	gc, err := githubClientFromEnv(ctx)
+	if err != nil {
+		return err
+	}
+	err = readLinearProjects()
+	if err != nil {
+		return err
+	}
+	err = loadGithubUserClients(ctx)
+	if err != nil {
+		return err
+	}
+
+	type issueDiscrepancies struct {
+		iss  *issueState
+		giss *githubIssue
+		snap *githubIssueSnapshot
+		ds   []*githubDiscrepancy
+	}
+	var found []*issueDiscrepancies
+	var checked, problems, repairable int
+	for _, iss := range s.Issues {
+		if !iss.ExportedToGithub || !filter.matchIdentifier(iss.Identifier) {
+			continue
+		}
+		liss, err := iss.linear()
+		if err != nil {
+			return err
+		}
+		if !filter.match(liss) {
+			continue
+		}
+		if iss.GithubNumber == 0 {
+			log.Printf("%s: skipped issue exported before github numbers were recorded", iss.Identifier)
+			continue
+		}
+
+		giss, err := fromLinearIssue(liss, withAuthor(liss))
+		if err != nil {
+			return err
+		}
+		if redactor != nil {
+			redactor.redact(giss)
+		}
+		snap, err := queryGithubIssue(ctx, gc.Client(), iss.GithubNumber)
+		if err != nil {
+			return fmt.Errorf("%s: %w", iss.Identifier, err)
+		}
+		checked++
+
+		ds := snap.compare(giss)
+		if len(ds) == 0 {
+			continue
+		}
+		idd := &issueDiscrepancies{iss: iss, giss: giss, snap: snap}
+		for _, d := range ds {
+			fmt.Printf("%s (#%d): %s: want %q, got %q\n", iss.Identifier, iss.GithubNumber, d.field, d.want, d.got)
+			problems++
+			if d.repairable {
+				idd.ds = append(idd.ds, d)
+			}
+		}
+		if len(idd.ds) > 0 {
+			found = append(found, idd)
+			repairable += len(idd.ds)
+		}
+	}
+
+	log.Printf("checked %d exported issues: %d discrepancies", checked, problems)
+	if problems == 0 {
+		return nil
+	}
+	if repairable == 0 || !confirm(fmt.Sprintf("repair %d discrepancies in %d issues?", repairable, len(found))) {
+		return fmt.Errorf("%d discrepancies found in %s/%s", problems, orgName, repoName)
+	}
+
+	for _, idd := range found {
+		log.Printf("%s: repairing #%d", idd.iss.Identifier, idd.iss.GithubNumber)
+		err = s.repairGithubIssue(ctx, gc, idd.iss, idd.giss, idd.snap, idd.ds)
+		if err != nil {
+			return fmt.Errorf("%s: %w", idd.iss.Identifier, err)
+		}
+	}
+	if repairable < problems {
+		return fmt.Errorf("%d discrepancies cannot be repaired", problems-repairable)
+	}
+	return nil
+}
+
+// repairGithubIssue updates the issue on GitHub to resolve ds.
+func (s *state) repairGithubIssue(ctx context.Context, gc *github.Client, iss *issueState, giss *githubIssue, snap *githubIssueSnapshot, ds []*githubDiscrepancy) error {
+	issReq := &github.IssueRequest{}
+	var edit, project bool
+	for _, d := range ds {
+		switch d.field {
+		case "title":
+			issReq.Title = &giss.title
+			edit = true
+		case "labels":
+			err := s.ensureLabels(ctx, gc, iss.Identifier, giss)
+			if err != nil {
+				return err
+			}
+			labels := []string{}
+			for _, l := range giss.labels {
+				labels = append(labels, l.name)
+			}
+			issReq.Labels = &labels
+			edit = true
+		case "assignee":
+			assignees := []string{}
+			if giss.assignee != "" {
+				assignees = append(assignees, giss.assignee)
+			}
+			issReq.Assignees = &assignees
+			edit = true
+		case "state":
+			issReq.State = github.String("open")
+			switch giss.state {
+			case "Done":
+				issReq.State = github.String("closed")
+				issReq.StateReason = github.String("completed")
+			case "Canceled":
+				issReq.State = github.String("closed")
+				issReq.StateReason = github.String("not_planned")
+			}
+			edit = true
+		case "project", "status":
+			project = true
+		}
+	}
+	if edit {
+		_, _, err := gc.Issues.Edit(ctx, orgName, repoName, iss.GithubNumber, issReq)
+		if err != nil {
+			return err
+		}
+	}
+	if project {
+		// Adding an issue that is already in the project returns the existing item so
+		// this only sets its fields.
+		err := s.addToProject(ctx, gc, iss.Identifier, giss, snap.ID)
+		if err != nil {
+			return err
+		}
+		return writeState(s)
+	}
+	return nil
+}